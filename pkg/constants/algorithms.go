@@ -0,0 +1,165 @@
+package constants
+
+var (
+	undirectedWeighted = []GraphType{{Directed: false, Weighted: true}}
+	undirected         = []GraphType{{Directed: false, Weighted: false}, {Directed: false, Weighted: true}}
+	weighted           = []GraphType{{Directed: false, Weighted: true}, {Directed: true, Weighted: true}}
+	anyGraph           = []GraphType{
+		{Directed: false, Weighted: false},
+		{Directed: false, Weighted: true},
+		{Directed: true, Weighted: false},
+		{Directed: true, Weighted: true},
+	}
+)
+
+// Appended to the descriptions of vertices in parameters and results. The inputs are normalized
+// to vertices numbered from 0, Matrix Market, METIS and DIMACS files are renumbered from 1 to 0.
+const numberedFromZero = ", numbered from 0 even when the input numbers vertices from 1"
+
+// Result of the spanning tree algorithms, the edges of the tree
+var spanningTreeResult = ResultSchema{
+	Kind: EdgeResult,
+	Columns: []Column{
+		{Name: "source", Type: IntegerParameter, Description: "First endpoint of the edge" + numberedFromZero},
+		{Name: "target", Type: IntegerParameter, Description: "Second endpoint of the edge" + numberedFromZero},
+		{Name: "weight", Type: NumberParameter, Description: "Weight of the edge"},
+	},
+}
+
+func sourceParameter(description string) Parameter {
+	return Parameter{
+		Name:        "source",
+		Type:        IntegerParameter,
+		Description: description + numberedFromZero,
+		Default:     "0",
+		Minimum:     float64Ptr(0),
+	}
+}
+
+func init() {
+	Register(AlgorithmSpec{
+		Name:        Kruskal,
+		Description: "Minimum spanning forest by Kruskal's algorithm, sorting the edges by weight across all ranks",
+		Parameters:  []Parameter{},
+		GraphTypes:  undirectedWeighted,
+		Result:      spanningTreeResult,
+		Entrypoint:  []string{"/pgasgraph/bin/kruskal"},
+	})
+
+	Register(AlgorithmSpec{
+		Name:        Prim,
+		Description: "Minimum spanning tree by Prim's algorithm, growing the tree from a root vertex",
+		Parameters: []Parameter{
+			{
+				Name:        "root",
+				Type:        IntegerParameter,
+				Description: "Vertex the tree is grown from" + numberedFromZero,
+				Default:     "0",
+				Minimum:     float64Ptr(0),
+			},
+		},
+		GraphTypes: undirectedWeighted,
+		Result:     spanningTreeResult,
+		Entrypoint: []string{"/pgasgraph/bin/prim"},
+	})
+
+	Register(AlgorithmSpec{
+		Name:        BFS,
+		Description: "Level-synchronous breadth-first search from a source vertex, ignoring edge weights",
+		Parameters: []Parameter{
+			sourceParameter("Vertex the search starts from"),
+		},
+		GraphTypes: anyGraph,
+		Result: ResultSchema{
+			Kind: VertexResult,
+			Columns: []Column{
+				{Name: "vertex", Type: IntegerParameter, Description: "Reached vertex" + numberedFromZero},
+				{Name: "level", Type: IntegerParameter, Description: "Number of edges on the path from the source"},
+				{Name: "parent", Type: IntegerParameter, Description: "Predecessor in the search tree, the source is its own parent"},
+			},
+		},
+		Entrypoint: []string{"/pgasgraph/bin/bfs"},
+	})
+
+	Register(AlgorithmSpec{
+		Name:        SSSP,
+		Description: "Single-source shortest paths by delta-stepping, relaxing the edges in buckets of distances",
+		Parameters: []Parameter{
+			sourceParameter("Vertex the distances are computed from"),
+			{
+				Name:        "delta",
+				Type:        NumberParameter,
+				Description: "Width of the distance buckets, 0 derives it from the average edge weight",
+				Default:     "0",
+				Minimum:     float64Ptr(0),
+			},
+		},
+		GraphTypes: weighted,
+		Result: ResultSchema{
+			Kind: VertexResult,
+			Columns: []Column{
+				{Name: "vertex", Type: IntegerParameter, Description: "Reached vertex" + numberedFromZero},
+				{Name: "distance", Type: NumberParameter, Description: "Weight of the shortest path from the source"},
+				{Name: "parent", Type: IntegerParameter, Description: "Predecessor on the shortest path, the source is its own parent"},
+			},
+		},
+		Entrypoint: []string{"/pgasgraph/bin/sssp"},
+	})
+
+	Register(AlgorithmSpec{
+		Name:        ConnectedComponents,
+		Description: "Connected components by label propagation with pointer jumping",
+		Parameters:  []Parameter{},
+		GraphTypes:  undirected,
+		Result: ResultSchema{
+			Kind: VertexResult,
+			Columns: []Column{
+				{Name: "vertex", Type: IntegerParameter, Description: "Vertex of the graph" + numberedFromZero},
+				{Name: "component", Type: IntegerParameter, Description: "Smallest vertex of the component the vertex belongs to"},
+			},
+		},
+		Entrypoint: []string{"/pgasgraph/bin/connected-components"},
+	})
+
+	Register(AlgorithmSpec{
+		Name:        PageRank,
+		Description: "PageRank by power iteration, edge weights are used as transition weights when present",
+		Parameters: []Parameter{
+			{
+				Name:        "damping",
+				Type:        NumberParameter,
+				Description: "Probability of following an edge instead of jumping to a random vertex",
+				Default:     "0.85",
+				Minimum:     float64Ptr(0),
+				Maximum:     float64Ptr(1),
+			},
+			{
+				Name:        "tolerance",
+				Type:        NumberParameter,
+				Description: "Iteration stops once the ranks change by less than this in total",
+				Default:     "0.000001",
+				Minimum:     float64Ptr(0),
+			},
+			{
+				Name:        "iterations",
+				Type:        IntegerParameter,
+				Description: "Maximum number of iterations",
+				Default:     "100",
+				Minimum:     float64Ptr(1),
+			},
+		},
+		GraphTypes: anyGraph,
+		Result: ResultSchema{
+			Kind: VertexResult,
+			Columns: []Column{
+				{Name: "vertex", Type: IntegerParameter, Description: "Vertex of the graph" + numberedFromZero},
+				{Name: "rank", Type: NumberParameter, Description: "PageRank of the vertex, the ranks sum up to 1"},
+			},
+		},
+		Entrypoint: []string{"/pgasgraph/bin/pagerank"},
+	})
+}
+
+func float64Ptr(f float64) *float64 {
+	return &f
+}
@@ -5,8 +5,12 @@ import "fmt"
 type Algorithm string
 
 const (
-	Kruskal = "kruskal"
-	Prim    = "mst"
+	Kruskal             Algorithm = "kruskal"
+	Prim                Algorithm = "prim"
+	BFS                 Algorithm = "bfs"
+	SSSP                Algorithm = "sssp"
+	ConnectedComponents Algorithm = "connected-components"
+	PageRank            Algorithm = "pagerank"
 )
 
 func (a *Algorithm) String() string {
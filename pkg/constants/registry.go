@@ -0,0 +1,200 @@
+package constants
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ParameterType is the type of the value of an algorithm parameter
+type ParameterType string
+
+const (
+	IntegerParameter ParameterType = "integer"
+	NumberParameter  ParameterType = "number"
+	StringParameter  ParameterType = "string"
+	BooleanParameter ParameterType = "boolean"
+)
+
+// Parameter describes a parameter accepted by an algorithm
+type Parameter struct {
+	Name        string        `json:"name"`
+	Type        ParameterType `json:"type"`
+	Description string        `json:"description"`
+	Required    bool          `json:"required,omitempty"`
+
+	// Value used when the parameter is not given
+	Default string `json:"default,omitempty"`
+
+	// Bounds of integer and number parameters, inclusive
+	Minimum *float64 `json:"minimum,omitempty"`
+	Maximum *float64 `json:"maximum,omitempty"`
+}
+
+// GraphType describes a kind of graph an algorithm runs on
+type GraphType struct {
+	Directed bool `json:"directed"`
+	Weighted bool `json:"weighted"`
+}
+
+// ResultKind tells what each record of an algorithm result describes
+type ResultKind string
+
+const (
+	// Each record is an edge of the resulting subgraph, e.g. of a spanning tree
+	EdgeResult ResultKind = "edges"
+
+	// Each record is a value computed for a vertex, e.g. its distance from the source
+	VertexResult ResultKind = "vertices"
+)
+
+// Column describes a column of the records of an algorithm result
+type Column struct {
+	Name        string        `json:"name"`
+	Type        ParameterType `json:"type"`
+	Description string        `json:"description"`
+}
+
+// ResultSchema describes the result an algorithm writes: one record per line, the columns
+// separated by whitespace
+type ResultSchema struct {
+	Kind    ResultKind `json:"kind"`
+	Columns []Column   `json:"columns"`
+}
+
+// AlgorithmSpec holds everything needed to offer an algorithm to users and run it
+type AlgorithmSpec struct {
+	Name        Algorithm    `json:"name"`
+	Description string       `json:"description"`
+	Parameters  []Parameter  `json:"parameters"`
+	GraphTypes  []GraphType  `json:"graphTypes"`
+	Result      ResultSchema `json:"result"`
+
+	// Command of the UPC++ application in the job image, the parameters are passed as
+	// --name=value arguments
+	Entrypoint []string `json:"entrypoint"`
+}
+
+var registry = map[Algorithm]AlgorithmSpec{}
+
+// Names algorithms were known by before, e.g. in existing UPCXX objects
+var legacyNames = map[Algorithm]Algorithm{
+	"mst": Prim,
+}
+
+// Register adds an algorithm to the registry. It panics when the algorithm is registered twice.
+func Register(spec AlgorithmSpec) {
+	if _, ok := registry[spec.Name]; ok {
+		panic(fmt.Sprintf("algorithm %s is already registered", spec.Name))
+	}
+	registry[spec.Name] = spec
+}
+
+// LookupAlgorithm returns the registered algorithm with the given name
+func LookupAlgorithm(name Algorithm) (AlgorithmSpec, bool) {
+	if current, ok := legacyNames[name]; ok {
+		name = current
+	}
+	spec, ok := registry[name]
+	return spec, ok
+}
+
+// Algorithms returns all registered algorithms ordered by name
+func Algorithms() []AlgorithmSpec {
+	specs := make([]AlgorithmSpec, 0, len(registry))
+	for _, spec := range registry {
+		specs = append(specs, spec)
+	}
+	sort.Slice(specs, func(i, j int) bool {
+		return specs[i].Name < specs[j].Name
+	})
+	return specs
+}
+
+// ValidateParameters checks the given parameters against the schema of the algorithm and
+// returns them with the defaults of the missing ones filled in
+func (s AlgorithmSpec) ValidateParameters(params map[string]string) (map[string]string, error) {
+	known := map[string]bool{}
+	validated := map[string]string{}
+
+	for _, param := range s.Parameters {
+		known[param.Name] = true
+
+		value, ok := params[param.Name]
+		if !ok {
+			if param.Required {
+				return nil, fmt.Errorf("parameter %s of %s is required", param.Name, s.Name)
+			}
+			if param.Default != "" {
+				validated[param.Name] = param.Default
+			}
+			continue
+		}
+
+		if err := param.validate(value); err != nil {
+			return nil, fmt.Errorf("parameter %s of %s: %w", param.Name, s.Name, err)
+		}
+		validated[param.Name] = value
+	}
+
+	for name := range params {
+		if !known[name] {
+			return nil, fmt.Errorf("%s has no parameter %s", s.Name, name)
+		}
+	}
+
+	return validated, nil
+}
+
+func (p Parameter) validate(value string) error {
+	var number float64
+	switch p.Type {
+	case IntegerParameter:
+		i, err := strconv.ParseInt(value, 10, 64)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		number = float64(i)
+	case NumberParameter:
+		f, err := strconv.ParseFloat(value, 64)
+		if err != nil || math.IsNaN(f) || math.IsInf(f, 0) {
+			return fmt.Errorf("%q is not a finite number", value)
+		}
+		number = f
+	case BooleanParameter:
+		if _, err := strconv.ParseBool(value); err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		return nil
+	default:
+		return nil
+	}
+
+	if p.Minimum != nil && number < *p.Minimum {
+		return fmt.Errorf("%s is less than %v", value, *p.Minimum)
+	}
+	if p.Maximum != nil && number > *p.Maximum {
+		return fmt.Errorf("%s is greater than %v", value, *p.Maximum)
+	}
+	return nil
+}
+
+// ParseRecord splits a line of the result into its columns, keyed by column name, and checks
+// the type of each value
+func (r ResultSchema) ParseRecord(line string) (map[string]string, error) {
+	fields := strings.Fields(line)
+	if len(fields) != len(r.Columns) {
+		return nil, fmt.Errorf("record %q has %d columns, expected %d", line, len(fields), len(r.Columns))
+	}
+
+	record := make(map[string]string, len(fields))
+	for i, column := range r.Columns {
+		if err := (Parameter{Type: column.Type}).validate(fields[i]); err != nil {
+			return nil, fmt.Errorf("column %s: %w", column.Name, err)
+		}
+		record[column.Name] = fields[i]
+	}
+	return record, nil
+}
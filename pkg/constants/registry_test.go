@@ -0,0 +1,115 @@
+package constants
+
+import (
+	"reflect"
+	"testing"
+)
+
+// An algorithm exercising every kind of parameter, not registered
+var testSpec = AlgorithmSpec{
+	Name: "test",
+	Parameters: []Parameter{
+		{Name: "count", Type: IntegerParameter, Required: true, Minimum: float64Ptr(1), Maximum: float64Ptr(10)},
+		{Name: "ratio", Type: NumberParameter, Default: "0.5", Minimum: float64Ptr(0), Maximum: float64Ptr(1)},
+		{Name: "verbose", Type: BooleanParameter},
+		{Name: "label", Type: StringParameter},
+	},
+}
+
+func TestValidateParameters(t *testing.T) {
+	tests := map[string]struct {
+		params map[string]string
+		want   map[string]string
+	}{
+		"defaults filled in": {
+			params: map[string]string{"count": "3"},
+			want:   map[string]string{"count": "3", "ratio": "0.5"},
+		},
+		"every parameter given": {
+			params: map[string]string{"count": "10", "ratio": "0", "verbose": "true", "label": "x"},
+			want:   map[string]string{"count": "10", "ratio": "0", "verbose": "true", "label": "x"},
+		},
+		"bounds are inclusive": {
+			params: map[string]string{"count": "1", "ratio": "1"},
+			want:   map[string]string{"count": "1", "ratio": "1"},
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, err := testSpec.ValidateParameters(tt.params)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("got %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestValidateParametersErrors(t *testing.T) {
+	tests := map[string]map[string]string{
+		"required missing":        {"ratio": "0.5"},
+		"unknown parameter":       {"count": "3", "color": "red"},
+		"integer below minimum":   {"count": "0"},
+		"integer above maximum":   {"count": "11"},
+		"number below minimum":    {"count": "3", "ratio": "-0.1"},
+		"number above maximum":    {"count": "3", "ratio": "1.5"},
+		"integer not an integer":  {"count": "2.5"},
+		"number not a number":     {"count": "3", "ratio": "half"},
+		"number NaN":              {"count": "3", "ratio": "NaN"},
+		"number infinite":         {"count": "3", "ratio": "+Inf"},
+		"boolean not a boolean":   {"count": "3", "verbose": "maybe"},
+		"empty value of required": {"count": ""},
+	}
+
+	for name, params := range tests {
+		t.Run(name, func(t *testing.T) {
+			if got, err := testSpec.ValidateParameters(params); err == nil {
+				t.Errorf("expected an error, got %v", got)
+			}
+		})
+	}
+}
+
+func TestLookupAlgorithm(t *testing.T) {
+	for _, name := range []Algorithm{Prim, "mst"} {
+		spec, ok := LookupAlgorithm(name)
+		if !ok {
+			t.Fatalf("%s is not registered", name)
+		}
+		if spec.Name != Prim {
+			t.Errorf("%s resolved to %s, want %s", name, spec.Name, Prim)
+		}
+	}
+
+	if _, ok := LookupAlgorithm("unknown"); ok {
+		t.Error("found an unregistered algorithm")
+	}
+}
+
+func TestParseRecord(t *testing.T) {
+	record, err := spanningTreeResult.ParseRecord("0 1\t2.5")
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string]string{"source": "0", "target": "1", "weight": "2.5"}
+	if !reflect.DeepEqual(record, want) {
+		t.Errorf("got %v, want %v", record, want)
+	}
+
+	for _, line := range []string{"0 1", "0 1 2 3", "0 x 2", "0 1 heavy", "0 1 NaN", "0 1 -Inf", ""} {
+		if record, err := spanningTreeResult.ParseRecord(line); err == nil {
+			t.Errorf("parsed %q as %v, expected an error", line, record)
+		}
+	}
+}
+
+func TestRegisteredDefaults(t *testing.T) {
+	for _, spec := range Algorithms() {
+		if _, err := spec.ValidateParameters(nil); err != nil {
+			t.Errorf("defaults of %s: %v", spec.Name, err)
+		}
+	}
+}
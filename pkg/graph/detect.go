@@ -0,0 +1,131 @@
+package graph
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"strings"
+)
+
+// Bytes looked at to detect the format of a graph
+const detectLength = 64 * 1024
+
+// Detect guesses the format of the graph from its beginning, without consuming it. Matrix
+// Market and DIMACS files are recognized by their header lines. Other files are edge lists,
+// separated by commas, tabs or spaces, unless their lines look like a METIS adjacency list.
+// Pass the format explicitly when the guess is wrong.
+func Detect(r *bufio.Reader) (Format, error) {
+	data, err := r.Peek(detectLength)
+	if err != nil && err != io.EOF && err != bufio.ErrBufferFull {
+		return "", err
+	}
+	complete := err == io.EOF
+
+	lines := strings.Split(string(data), "\n")
+	if !complete {
+		// The last line may be cut off
+		lines = lines[:len(lines)-1]
+	}
+
+	var first string
+	for _, line := range lines {
+		if line = strings.TrimSpace(line); line != "" {
+			first = line
+			break
+		}
+	}
+
+	switch {
+	case strings.HasPrefix(strings.ToLower(first), strings.ToLower(matrixMarketBanner)):
+		return MatrixMarket, nil
+	case first == "c" || strings.HasPrefix(first, "c ") || strings.HasPrefix(first, "p "):
+		return DIMACS, nil
+	case bytes.Contains(data, []byte(",")):
+		return CSV, nil
+	case bytes.Contains(data, []byte("\t")):
+		return TSV, nil
+	case looksLikeMETIS(lines, complete):
+		return METIS, nil
+	}
+	return EdgeList, nil
+}
+
+// looksLikeMETIS tells whether the lines are a METIS header followed by adjacency lists rather
+// than an edge list. Edge lists hold two or three values on every line, so other lines give a
+// METIS file away. Otherwise the whole file has to match the counts of the METIS header.
+func looksLikeMETIS(lines []string, complete bool) bool {
+	var data []string
+	for _, line := range lines {
+		line = strings.TrimSpace(line)
+		if strings.HasPrefix(line, "#") {
+			return false
+		}
+		if !strings.HasPrefix(line, "%") && (line != "" || len(data) > 0) {
+			data = append(data, line)
+		}
+	}
+	if len(data) == 0 {
+		return false
+	}
+
+	header := strings.Fields(data[0])
+	if len(header) < 2 || len(header) > 3 {
+		return false
+	}
+	vertices, err := parseCount(header[0])
+	if err != nil {
+		return false
+	}
+	edges, err := parseCount(header[1])
+	if err != nil {
+		return false
+	}
+	// Only edge weights are told apart, vertex weights make the lines too irregular
+	weighted := len(header) == 3
+	if weighted && strings.TrimLeft(header[2], "0") != "1" {
+		return false
+	}
+
+	body := data[1:]
+	for len(body) > 0 && body[len(body)-1] == "" {
+		body = body[:len(body)-1]
+	}
+
+	var values int64
+	for _, line := range body {
+		fields := strings.Fields(line)
+		if line != "" && len(fields) != 2 && len(fields) != 3 {
+			return true
+		}
+		for i, field := range fields {
+			if weighted && i%2 == 1 {
+				continue
+			}
+			if _, err := parseVertex(field, 1, vertices); err != nil {
+				return false
+			}
+		}
+		if weighted && len(fields)%2 != 0 {
+			return false
+		}
+		values += int64(len(fields))
+	}
+
+	perEdge := int64(2)
+	if weighted {
+		perEdge = 4
+	}
+	return complete && int64(len(body)) == vertices && values == perEdge*edges
+}
+
+// NewDetectingReader returns a reader of the graph in the format guessed by Detect
+func NewDetectingReader(r io.Reader) (Reader, Format, error) {
+	buffered := bufio.NewReaderSize(r, detectLength)
+	format, err := Detect(buffered)
+	if err != nil {
+		return nil, "", err
+	}
+
+	reader, err := NewReader(buffered, format)
+	return reader, format, err
+}
@@ -0,0 +1,146 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+// dimacsReader reads DIMACS shortest path files: "c" comment lines, a "p sp vertices arcs"
+// problem line and one "a source target weight" line per arc
+type dimacsReader struct {
+	lines  *lineScanner
+	header Header
+	read   int64
+}
+
+func newDIMACSReader(r io.Reader) (*dimacsReader, error) {
+	reader := &dimacsReader{lines: newLineScanner(r)}
+	reader.header.Directed = true
+	reader.header.Weighted = true
+
+	line, err := reader.nextLine()
+	if err == io.EOF {
+		return nil, reader.lines.errorf("missing DIMACS problem line")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != "p" || fields[1] != "sp" {
+		return nil, reader.lines.errorf("expected \"p sp vertices arcs\", got %q", line)
+	}
+	if reader.header.Vertices, err = parseCount(fields[2]); err != nil {
+		return nil, reader.lines.errorf("%v", err)
+	}
+	if reader.header.Edges, err = parseCount(fields[3]); err != nil {
+		return nil, reader.lines.errorf("%v", err)
+	}
+
+	return reader, nil
+}
+
+// nextLine skips comments, which are lines consisting of or starting with a "c" field
+func (r *dimacsReader) nextLine() (string, error) {
+	for {
+		line, err := r.lines.nextData()
+		if err != nil {
+			return "", err
+		}
+		if line != "c" && !strings.HasPrefix(line, "c ") && !strings.HasPrefix(line, "c\t") {
+			return line, nil
+		}
+	}
+}
+
+func (r *dimacsReader) Header() Header {
+	return r.header
+}
+
+func (r *dimacsReader) Read() (Edge, error) {
+	line, err := r.nextLine()
+	if err == io.EOF {
+		if err := checkCount(DIMACS, r.header.Edges, r.read); err != nil {
+			return Edge{}, err
+		}
+		return Edge{}, io.EOF
+	}
+	if err != nil {
+		return Edge{}, err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) != 4 || fields[0] != "a" {
+		return Edge{}, r.lines.errorf("expected \"a source target weight\", got %q", line)
+	}
+
+	edge := Edge{}
+	if edge.Source, err = parseVertex(fields[1], 1, r.header.Vertices); err != nil {
+		return Edge{}, r.lines.errorf("%v", err)
+	}
+	if edge.Target, err = parseVertex(fields[2], 1, r.header.Vertices); err != nil {
+		return Edge{}, r.lines.errorf("%v", err)
+	}
+	if edge.Weight, err = parseWeight(fields[3]); err != nil {
+		return Edge{}, r.lines.errorf("%v", err)
+	}
+
+	r.read++
+	if r.read > r.header.Edges {
+		return Edge{}, r.lines.errorf("more arcs than the %d declared", r.header.Edges)
+	}
+	return edge, nil
+}
+
+// dimacsWriter writes every undirected edge as a pair of arcs, since DIMACS graphs are directed
+type dimacsWriter struct {
+	w       *bufio.Writer
+	header  Header
+	written int64
+}
+
+func newDIMACSWriter(w io.Writer, header Header) (*dimacsWriter, error) {
+	if header.Vertices == 0 && header.Edges > 0 {
+		return nil, fmt.Errorf("%s needs the number of vertices in the header", DIMACS)
+	}
+
+	arcs := header.Edges
+	if !header.Directed {
+		arcs *= 2
+	}
+
+	writer := &dimacsWriter{w: bufio.NewWriter(w), header: header}
+	_, err := fmt.Fprintf(writer.w, "p sp %d %d\n", header.Vertices, arcs)
+	return writer, err
+}
+
+func (w *dimacsWriter) Write(edge Edge) error {
+	if edge.Source >= w.header.Vertices || edge.Target >= w.header.Vertices {
+		return fmt.Errorf("edge %d-%d exceeds the %d vertices of the header", edge.Source, edge.Target, w.header.Vertices)
+	}
+
+	if err := w.writeArc(edge.Source, edge.Target, edge.Weight); err != nil {
+		return err
+	}
+	if !w.header.Directed {
+		if err := w.writeArc(edge.Target, edge.Source, edge.Weight); err != nil {
+			return err
+		}
+	}
+	w.written++
+	return nil
+}
+
+func (w *dimacsWriter) writeArc(source, target int64, weight float64) error {
+	_, err := w.w.WriteString("a " + formatVertex(source, 1) + " " + formatVertex(target, 1) + " " + formatWeight(weight) + "\n")
+	return err
+}
+
+func (w *dimacsWriter) Close() error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	return checkCount(DIMACS, w.header.Edges, w.written)
+}
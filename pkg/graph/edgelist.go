@@ -0,0 +1,138 @@
+package graph
+
+import (
+	"bufio"
+	"io"
+	"strings"
+)
+
+var separators = map[Format]string{
+	EdgeList: " ",
+	CSV:      ",",
+	TSV:      "\t",
+}
+
+// edgeListReader reads edge lists. Lines starting with # are comments, a first line with
+// column names is skipped. Either all edges are weighted or none is.
+type edgeListReader struct {
+	lines  *lineScanner
+	format Format
+	header Header
+
+	// Number of fields of every line, set by the first edge
+	columns int
+
+	// The first edge is read ahead to tell whether the edges are weighted
+	pending *Edge
+}
+
+func newEdgeListReader(r io.Reader, format Format) (*edgeListReader, error) {
+	reader := &edgeListReader{lines: newLineScanner(r), format: format}
+
+	line, err := reader.lines.nextData("#")
+	if err == io.EOF {
+		return reader, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := reader.split(line)
+	if _, err := parseVertex(fields[0], 0, 0); err != nil {
+		// Column names
+		if line, err = reader.lines.nextData("#"); err == io.EOF {
+			return reader, nil
+		}
+		if err != nil {
+			return nil, err
+		}
+		fields = reader.split(line)
+	}
+
+	edge, err := reader.parse(fields)
+	if err != nil {
+		return nil, err
+	}
+	reader.pending = &edge
+	reader.header.Weighted = reader.columns == 3
+	return reader, nil
+}
+
+func (r *edgeListReader) split(line string) []string {
+	if r.format == EdgeList {
+		return strings.Fields(line)
+	}
+
+	fields := strings.Split(line, separators[r.format])
+	for i := range fields {
+		fields[i] = strings.TrimSpace(fields[i])
+	}
+	return fields
+}
+
+func (r *edgeListReader) parse(fields []string) (Edge, error) {
+	if len(fields) != 2 && len(fields) != 3 {
+		return Edge{}, r.lines.errorf("expected source, target and optionally weight, got %d fields", len(fields))
+	}
+	if r.columns == 0 {
+		r.columns = len(fields)
+	} else if len(fields) != r.columns {
+		return Edge{}, r.lines.errorf("expected %d fields like the first edge, got %d", r.columns, len(fields))
+	}
+
+	edge := Edge{Weight: 1}
+	var err error
+	if edge.Source, err = parseVertex(fields[0], 0, 0); err != nil {
+		return Edge{}, r.lines.errorf("%v", err)
+	}
+	if edge.Target, err = parseVertex(fields[1], 0, 0); err != nil {
+		return Edge{}, r.lines.errorf("%v", err)
+	}
+	if len(fields) == 3 {
+		if edge.Weight, err = parseWeight(fields[2]); err != nil {
+			return Edge{}, r.lines.errorf("%v", err)
+		}
+	}
+	return edge, nil
+}
+
+func (r *edgeListReader) Header() Header {
+	return r.header
+}
+
+func (r *edgeListReader) Read() (Edge, error) {
+	if r.pending != nil {
+		edge := *r.pending
+		r.pending = nil
+		return edge, nil
+	}
+
+	line, err := r.lines.nextData("#")
+	if err != nil {
+		return Edge{}, err
+	}
+	return r.parse(r.split(line))
+}
+
+type edgeListWriter struct {
+	w         *bufio.Writer
+	separator string
+	weighted  bool
+}
+
+func newEdgeListWriter(w io.Writer, format Format, header Header) *edgeListWriter {
+	return &edgeListWriter{w: bufio.NewWriter(w), separator: separators[format], weighted: header.Weighted}
+}
+
+func (w *edgeListWriter) Write(edge Edge) error {
+	fields := []string{formatVertex(edge.Source, 0), formatVertex(edge.Target, 0)}
+	if w.weighted {
+		fields = append(fields, formatWeight(edge.Weight))
+	}
+	_, err := w.w.WriteString(strings.Join(fields, w.separator) + "\n")
+	return err
+}
+
+func (w *edgeListWriter) Close() error {
+	return w.w.Flush()
+}
@@ -0,0 +1,3 @@
+module github.com/lnikon/glfs-pkg/pkg/graph
+
+go 1.17
@@ -0,0 +1,244 @@
+// Package graph reads and writes the graph file formats accepted as job inputs. Readers and
+// writers stream the edges one at a time, only the METIS writer has to hold the whole graph.
+// Vertices are numbered from 0, formats numbering from 1 are converted when read and written.
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// Format is a graph file format
+type Format string
+
+const (
+	// EdgeList is one "source target [weight]" edge per line separated by whitespace. It is the
+	// format the jobs read their input in.
+	EdgeList Format = "edgelist"
+
+	// CSV is an edge list with comma separated "source,target[,weight]" lines
+	CSV Format = "csv"
+
+	// TSV is an edge list with tab separated lines
+	TSV Format = "tsv"
+
+	// MatrixMarket is the coordinate format of Matrix Market .mtx files, the graph being the
+	// adjacency matrix
+	MatrixMarket Format = "mtx"
+
+	// METIS is the adjacency list format of the METIS partitioner, for undirected graphs
+	METIS Format = "metis"
+
+	// DIMACS is the shortest path format of the 9th DIMACS challenge, for directed graphs
+	DIMACS Format = "dimacs"
+)
+
+// Formats lists all supported formats
+var Formats = []Format{EdgeList, CSV, TSV, MatrixMarket, METIS, DIMACS}
+
+// ParseFormat returns the format with the given name
+func ParseFormat(name string) (Format, error) {
+	for _, format := range Formats {
+		if Format(strings.ToLower(name)) == format {
+			return format, nil
+		}
+	}
+	return "", fmt.Errorf("unknown graph format %q", name)
+}
+
+// Edge is an edge of a graph. Undirected edges are read and written once.
+type Edge struct {
+	Source int64
+	Target int64
+	Weight float64
+}
+
+// Header describes the graph as far as its file declares it
+type Header struct {
+	// Number of vertices, zero when not declared
+	Vertices int64
+
+	// Number of edges, zero when not declared
+	Edges int64
+
+	// Edge lists do not declare whether they are directed, they are read as undirected
+	Directed bool
+
+	// Edges of unweighted graphs get weight 1
+	Weighted bool
+}
+
+// Reader reads a graph edge by edge
+type Reader interface {
+	// Header describes the graph, it is read along with the reader
+	Header() Header
+
+	// Read returns the next edge, or io.EOF after the last one. Edge counts declared in the
+	// header are checked at the end of the file.
+	Read() (Edge, error)
+}
+
+// Writer writes a graph edge by edge
+type Writer interface {
+	Write(edge Edge) error
+
+	// Close writes what is left of the graph and checks that the edges match the header.
+	// It does not close the underlying io.Writer.
+	Close() error
+}
+
+// NewReader returns a reader of the graph in the given format
+func NewReader(r io.Reader, format Format) (Reader, error) {
+	switch format {
+	case EdgeList, CSV, TSV:
+		return newEdgeListReader(r, format)
+	case MatrixMarket:
+		return newMatrixMarketReader(r)
+	case METIS:
+		return newMETISReader(r)
+	case DIMACS:
+		return newDIMACSReader(r)
+	}
+	return nil, fmt.Errorf("unknown graph format %q", format)
+}
+
+// NewWriter returns a writer of a graph in the given format. Matrix Market and DIMACS declare
+// the vertex and edge counts before the edges, so they have to be given in the header.
+func NewWriter(w io.Writer, format Format, header Header) (Writer, error) {
+	switch format {
+	case EdgeList, CSV, TSV:
+		return newEdgeListWriter(w, format, header), nil
+	case MatrixMarket:
+		return newMatrixMarketWriter(w, header)
+	case METIS:
+		return newMETISWriter(w, header)
+	case DIMACS:
+		return newDIMACSWriter(w, header)
+	}
+	return nil, fmt.Errorf("unknown graph format %q", format)
+}
+
+// Copy writes all edges of the reader to the writer and closes the writer. It returns the
+// number of edges copied.
+func Copy(w Writer, r Reader) (int64, error) {
+	var edges int64
+	for {
+		edge, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return edges, err
+		}
+		if err := w.Write(edge); err != nil {
+			return edges, err
+		}
+		edges++
+	}
+	return edges, w.Close()
+}
+
+// Lines longer than the default buffer of bufio.Scanner are common in METIS files, which list
+// all neighbours of a vertex on one line
+const maxLineLength = 64 * 1024 * 1024
+
+// lineScanner reads a file line by line, keeping the line number for error messages
+type lineScanner struct {
+	scanner *bufio.Scanner
+	line    int
+}
+
+func newLineScanner(r io.Reader) *lineScanner {
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), maxLineLength)
+	return &lineScanner{scanner: scanner}
+}
+
+// next returns the next line, trimmed, or io.EOF after the last one
+func (s *lineScanner) next() (string, error) {
+	if !s.scanner.Scan() {
+		if err := s.scanner.Err(); err != nil {
+			return "", err
+		}
+		return "", io.EOF
+	}
+	s.line++
+	return strings.TrimSpace(s.scanner.Text()), nil
+}
+
+// nextData returns the next line which is neither empty nor starts with one of the comment
+// prefixes
+func (s *lineScanner) nextData(comments ...string) (string, error) {
+	for {
+		line, err := s.next()
+		if err != nil {
+			return "", err
+		}
+		if line != "" && !hasAnyPrefix(line, comments) {
+			return line, nil
+		}
+	}
+}
+
+func (s *lineScanner) errorf(format string, args ...interface{}) error {
+	return fmt.Errorf("line %d: %s", s.line, fmt.Sprintf(format, args...))
+}
+
+func hasAnyPrefix(line string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(line, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+func parseCount(field string) (int64, error) {
+	count, err := strconv.ParseInt(field, 10, 64)
+	if err != nil || count < 0 {
+		return 0, fmt.Errorf("%q is not a non-negative integer", field)
+	}
+	return count, nil
+}
+
+func parseWeight(field string) (float64, error) {
+	weight, err := strconv.ParseFloat(field, 64)
+	if err != nil || math.IsNaN(weight) || math.IsInf(weight, 0) {
+		return 0, fmt.Errorf("weight %q is not a finite number", field)
+	}
+	return weight, nil
+}
+
+// parseVertex parses a vertex numbered from base and checks it against the declared number
+// of vertices, unless that is unknown
+func parseVertex(field string, base, vertices int64) (int64, error) {
+	vertex, err := strconv.ParseInt(field, 10, 64)
+	if err != nil || vertex < base || (vertices > 0 && vertex-base >= vertices) {
+		if vertices > 0 {
+			return 0, fmt.Errorf("vertex %q is not between %d and %d", field, base, vertices-1+base)
+		}
+		return 0, fmt.Errorf("vertex %q is not an integer of at least %d", field, base)
+	}
+	return vertex - base, nil
+}
+
+// formatVertex numbers the vertex from base
+func formatVertex(vertex, base int64) string {
+	return strconv.FormatInt(vertex+base, 10)
+}
+
+func formatWeight(weight float64) string {
+	return strconv.FormatFloat(weight, 'g', -1, 64)
+}
+
+// checkCount compares the number of edges read or written with the declared one
+func checkCount(format Format, declared, actual int64) error {
+	if declared != actual {
+		return fmt.Errorf("%s header declares %d edges, found %d", format, declared, actual)
+	}
+	return nil
+}
@@ -0,0 +1,235 @@
+package graph
+
+import (
+	"bufio"
+	"bytes"
+	"io"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// A weighted triangle with a tail, every edge listed from its smaller vertex
+var sampleEdges = []Edge{
+	{0, 1, 1.5},
+	{0, 2, 3},
+	{1, 2, 2},
+	{2, 3, 4},
+}
+
+var sampleFiles = map[Format]string{
+	EdgeList: "# source target weight\n0 1 1.5\n0 2 3\n1 2 2\n2 3 4\n",
+	CSV:      "source,target,weight\n0,1,1.5\n0,2,3\n1,2,2\n2,3,4\n",
+	TSV:      "0\t1\t1.5\n0\t2\t3\n1\t2\t2\n2\t3\t4\n",
+	MatrixMarket: `%%MatrixMarket matrix coordinate real symmetric
+% a weighted triangle with a tail
+4 4 4
+2 1 1.5
+3 1 3
+3 2 2
+4 3 4
+`,
+	METIS: `% a weighted triangle with a tail
+4 4 001
+2 1.5 3 3
+1 1.5 3 2
+1 3 2 2 4 4
+3 4
+`,
+	DIMACS: `c a weighted triangle with a tail
+p sp 4 4
+a 1 2 1.5
+a 1 3 3
+a 2 3 2
+a 3 4 4
+`,
+}
+
+func readAll(t *testing.T, r Reader) []Edge {
+	t.Helper()
+	var edges []Edge
+	for {
+		edge, err := r.Read()
+		if err == io.EOF {
+			return edges
+		}
+		if err != nil {
+			t.Fatalf("unable to read edge: %v", err)
+		}
+		edges = append(edges, edge)
+	}
+}
+
+func TestReaders(t *testing.T) {
+	for format, file := range sampleFiles {
+		t.Run(string(format), func(t *testing.T) {
+			reader, err := NewReader(strings.NewReader(file), format)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if !reader.Header().Weighted {
+				t.Error("expected a weighted graph")
+			}
+			if got := readAll(t, reader); !reflect.DeepEqual(got, sampleEdges) {
+				t.Errorf("got %v, want %v", got, sampleEdges)
+			}
+		})
+	}
+}
+
+func TestWriters(t *testing.T) {
+	for format := range sampleFiles {
+		t.Run(string(format), func(t *testing.T) {
+			// DIMACS would write every undirected edge twice
+			header := Header{Vertices: 4, Edges: 4, Weighted: true, Directed: format == DIMACS}
+
+			var out bytes.Buffer
+			writer, err := NewWriter(&out, format, header)
+			if err != nil {
+				t.Fatal(err)
+			}
+			for _, edge := range sampleEdges {
+				if err := writer.Write(edge); err != nil {
+					t.Fatal(err)
+				}
+			}
+			if err := writer.Close(); err != nil {
+				t.Fatal(err)
+			}
+
+			reader, err := NewReader(&out, format)
+			if err != nil {
+				t.Fatalf("unable to read back %s:\n%s", err, out.String())
+			}
+			if got := readAll(t, reader); !reflect.DeepEqual(got, sampleEdges) {
+				t.Errorf("read back %v, want %v", got, sampleEdges)
+			}
+		})
+	}
+}
+
+func TestUnweighted(t *testing.T) {
+	files := map[Format]string{
+		EdgeList:     "0 1\n1 2\n",
+		MatrixMarket: "%%MatrixMarket matrix coordinate pattern general\n3 3 2\n1 2\n2 3\n",
+		METIS:        "3 2\n2\n1 3\n2\n",
+	}
+	want := []Edge{{0, 1, 1}, {1, 2, 1}}
+
+	for format, file := range files {
+		reader, err := NewReader(strings.NewReader(file), format)
+		if err != nil {
+			t.Fatalf("%s: %v", format, err)
+		}
+		if reader.Header().Weighted {
+			t.Errorf("%s: expected an unweighted graph", format)
+		}
+		if got := readAll(t, reader); !reflect.DeepEqual(got, want) {
+			t.Errorf("%s: got %v, want %v", format, got, want)
+		}
+	}
+}
+
+func TestInvalidFiles(t *testing.T) {
+	files := map[string]struct {
+		format Format
+		file   string
+	}{
+		"edge list with a negative vertex": {EdgeList, "0 1\n-1 2\n"},
+		"csv with four columns":            {CSV, "0,1,2,3\n"},
+		"edge list mixing weights":         {EdgeList, "0 1\n1 2 3\n"},
+		"csv mixing weights":               {CSV, "0,1,2\n1,2\n"},
+		"edge list with a NaN weight":      {EdgeList, "0 1 NaN\n"},
+		"csv with an infinite weight":      {CSV, "0,1,+Inf\n"},
+		"mtx with an infinite weight":      {MatrixMarket, "%%MatrixMarket matrix coordinate real general\n2 2 1\n1 2 -inf\n"},
+		"mtx array":                        {MatrixMarket, "%%MatrixMarket matrix array real general\n2 2\n1\n2\n3\n4\n"},
+		"mtx not square":                   {MatrixMarket, "%%MatrixMarket matrix coordinate real general\n2 3 1\n1 2 1\n"},
+		"mtx vertex out of range":          {MatrixMarket, "%%MatrixMarket matrix coordinate real general\n2 2 1\n1 3 1\n"},
+		"mtx missing entries":              {MatrixMarket, "%%MatrixMarket matrix coordinate real general\n2 2 2\n1 2 1\n"},
+		"metis missing vertices":           {METIS, "3 1\n2\n1\n"},
+		"metis wrong edge count":           {METIS, "3 1\n2 3\n1\n1\n"},
+		"metis self loop":                  {METIS, "2 1\n1\n2\n"},
+		"dimacs without problem line":      {DIMACS, "a 1 2 3\n"},
+		"dimacs extra arc":                 {DIMACS, "p sp 2 1\na 1 2 3\na 2 1 3\n"},
+	}
+
+	for name, tt := range files {
+		t.Run(name, func(t *testing.T) {
+			reader, err := NewReader(strings.NewReader(tt.file), tt.format)
+			if err != nil {
+				return
+			}
+			for {
+				_, err := reader.Read()
+				if err == io.EOF {
+					t.Fatal("expected an error")
+				}
+				if err != nil {
+					return
+				}
+			}
+		})
+	}
+}
+
+func TestDetect(t *testing.T) {
+	for format, file := range sampleFiles {
+		got, err := Detect(bufio.NewReader(strings.NewReader(file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != format {
+			t.Errorf("detected %s as %s", format, got)
+		}
+	}
+
+	files := map[string]Format{
+		// Would be a METIS header, but the counts do not match
+		"3 2\n0 1\n1 2\n2 0\n":  EdgeList,
+		"3 2\n2\n1 3\n2\n":      METIS,
+		"4 3\n2 3 4\n1\n1\n1\n": METIS,
+		"1 2\n2 3\n":            EdgeList,
+	}
+	for file, format := range files {
+		got, err := Detect(bufio.NewReader(strings.NewReader(file)))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if got != format {
+			t.Errorf("detected %q as %s, want %s", file, got, format)
+		}
+	}
+}
+
+func TestNewDetectingReader(t *testing.T) {
+	reader, format, err := NewDetectingReader(strings.NewReader(sampleFiles[MatrixMarket]))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if format != MatrixMarket {
+		t.Errorf("detected %s", format)
+	}
+	if got := readAll(t, reader); !reflect.DeepEqual(got, sampleEdges) {
+		t.Errorf("got %v, want %v", got, sampleEdges)
+	}
+}
+
+func TestCopy(t *testing.T) {
+	reader, err := NewReader(strings.NewReader(sampleFiles[METIS]), METIS)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	var out bytes.Buffer
+	writer, err := NewWriter(&out, EdgeList, reader.Header())
+	if err != nil {
+		t.Fatal(err)
+	}
+	edges, err := Copy(writer, reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if edges != 4 || out.String() != "0 1 1.5\n0 2 3\n1 2 2\n2 3 4\n" {
+		t.Errorf("copied %d edges:\n%s", edges, out.String())
+	}
+}
@@ -0,0 +1,176 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"strings"
+)
+
+const matrixMarketBanner = "%%MatrixMarket"
+
+// matrixMarketReader reads the coordinate format of square real, integer or pattern matrices.
+// General matrices are directed graphs, symmetric ones undirected graphs listing every edge
+// once.
+type matrixMarketReader struct {
+	lines  *lineScanner
+	header Header
+	read   int64
+}
+
+func newMatrixMarketReader(r io.Reader) (*matrixMarketReader, error) {
+	reader := &matrixMarketReader{lines: newLineScanner(r)}
+
+	banner, err := reader.lines.next()
+	if err != nil && err != io.EOF {
+		return nil, err
+	}
+	fields := strings.Fields(strings.ToLower(banner))
+	if len(fields) != 5 || fields[0] != strings.ToLower(matrixMarketBanner) || fields[1] != "matrix" {
+		return nil, reader.lines.errorf("expected a %s matrix banner", matrixMarketBanner)
+	}
+	if fields[2] != "coordinate" {
+		return nil, reader.lines.errorf("only coordinate matrices hold graphs, got %s", fields[2])
+	}
+
+	switch fields[3] {
+	case "real", "integer":
+		reader.header.Weighted = true
+	case "pattern":
+	default:
+		return nil, reader.lines.errorf("unsupported matrix field %s", fields[3])
+	}
+
+	switch fields[4] {
+	case "general":
+		reader.header.Directed = true
+	case "symmetric":
+	default:
+		return nil, reader.lines.errorf("unsupported matrix symmetry %s", fields[4])
+	}
+
+	size, err := reader.lines.nextData("%")
+	if err == io.EOF {
+		return nil, reader.lines.errorf("missing matrix size")
+	}
+	if err != nil {
+		return nil, err
+	}
+	counts := strings.Fields(size)
+	if len(counts) != 3 {
+		return nil, reader.lines.errorf("expected rows, columns and entries, got %q", size)
+	}
+	var rows, columns int64
+	for i, count := range []*int64{&rows, &columns, &reader.header.Edges} {
+		if *count, err = parseCount(counts[i]); err != nil {
+			return nil, reader.lines.errorf("%v", err)
+		}
+	}
+	if rows != columns {
+		return nil, reader.lines.errorf("adjacency matrix must be square, got %dx%d", rows, columns)
+	}
+	reader.header.Vertices = rows
+
+	return reader, nil
+}
+
+func (r *matrixMarketReader) Header() Header {
+	return r.header
+}
+
+func (r *matrixMarketReader) Read() (Edge, error) {
+	line, err := r.lines.nextData("%")
+	if err == io.EOF {
+		if err := checkCount(MatrixMarket, r.header.Edges, r.read); err != nil {
+			return Edge{}, err
+		}
+		return Edge{}, io.EOF
+	}
+	if err != nil {
+		return Edge{}, err
+	}
+
+	fields := strings.Fields(line)
+	want := 2
+	if r.header.Weighted {
+		want = 3
+	}
+	if len(fields) != want {
+		return Edge{}, r.lines.errorf("expected %d fields, got %d", want, len(fields))
+	}
+
+	edge := Edge{Weight: 1}
+	if edge.Source, err = parseVertex(fields[0], 1, r.header.Vertices); err != nil {
+		return Edge{}, r.lines.errorf("%v", err)
+	}
+	if edge.Target, err = parseVertex(fields[1], 1, r.header.Vertices); err != nil {
+		return Edge{}, r.lines.errorf("%v", err)
+	}
+	if r.header.Weighted {
+		if edge.Weight, err = parseWeight(fields[2]); err != nil {
+			return Edge{}, r.lines.errorf("%v", err)
+		}
+	}
+
+	// Symmetric matrices store the lower triangle, edges are read from their smaller vertex
+	if !r.header.Directed && edge.Source > edge.Target {
+		edge.Source, edge.Target = edge.Target, edge.Source
+	}
+
+	r.read++
+	if r.read > r.header.Edges {
+		return Edge{}, r.lines.errorf("more entries than the %d declared", r.header.Edges)
+	}
+	return edge, nil
+}
+
+type matrixMarketWriter struct {
+	w       *bufio.Writer
+	header  Header
+	written int64
+}
+
+func newMatrixMarketWriter(w io.Writer, header Header) (*matrixMarketWriter, error) {
+	if header.Vertices == 0 && header.Edges > 0 {
+		return nil, fmt.Errorf("%s needs the number of vertices in the header", MatrixMarket)
+	}
+
+	field, symmetry := "pattern", "symmetric"
+	if header.Weighted {
+		field = "real"
+	}
+	if header.Directed {
+		symmetry = "general"
+	}
+
+	writer := &matrixMarketWriter{w: bufio.NewWriter(w), header: header}
+	_, err := fmt.Fprintf(writer.w, "%s matrix coordinate %s %s\n%d %d %d\n",
+		matrixMarketBanner, field, symmetry, header.Vertices, header.Vertices, header.Edges)
+	return writer, err
+}
+
+func (w *matrixMarketWriter) Write(edge Edge) error {
+	if edge.Source >= w.header.Vertices || edge.Target >= w.header.Vertices {
+		return fmt.Errorf("edge %d-%d exceeds the %d vertices of the header", edge.Source, edge.Target, w.header.Vertices)
+	}
+
+	// Symmetric matrices store the lower triangle
+	if !w.header.Directed && edge.Source < edge.Target {
+		edge.Source, edge.Target = edge.Target, edge.Source
+	}
+
+	line := formatVertex(edge.Source, 1) + " " + formatVertex(edge.Target, 1)
+	if w.header.Weighted {
+		line += " " + formatWeight(edge.Weight)
+	}
+	w.written++
+	_, err := w.w.WriteString(line + "\n")
+	return err
+}
+
+func (w *matrixMarketWriter) Close() error {
+	if err := w.w.Flush(); err != nil {
+		return err
+	}
+	return checkCount(MatrixMarket, w.header.Edges, w.written)
+}
@@ -0,0 +1,221 @@
+package graph
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// metisReader reads METIS graph files: a "vertices edges [fmt [ncon]]" header followed by one
+// line per vertex listing its neighbours. Every undirected edge is listed by both of its
+// vertices and read once. Vertex sizes and weights are skipped.
+type metisReader struct {
+	lines  *lineScanner
+	header Header
+
+	// Values preceding the neighbours on every vertex line
+	vertexValues int
+
+	vertex    int64
+	neighbors []string
+	read      int64
+}
+
+func newMETISReader(r io.Reader) (*metisReader, error) {
+	reader := &metisReader{lines: newLineScanner(r), vertex: -1}
+
+	line, err := reader.lines.nextData("%")
+	if err == io.EOF {
+		return nil, reader.lines.errorf("missing METIS header")
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	fields := strings.Fields(line)
+	if len(fields) < 2 || len(fields) > 4 {
+		return nil, reader.lines.errorf("expected \"vertices edges [fmt [ncon]]\", got %q", line)
+	}
+	if reader.header.Vertices, err = parseCount(fields[0]); err != nil {
+		return nil, reader.lines.errorf("%v", err)
+	}
+	if reader.header.Edges, err = parseCount(fields[1]); err != nil {
+		return nil, reader.lines.errorf("%v", err)
+	}
+
+	format := "000"
+	if len(fields) > 2 {
+		format = fields[2]
+		if len(format) > 3 || strings.Trim(format, "01") != "" {
+			return nil, reader.lines.errorf("invalid fmt %q", format)
+		}
+		format = strings.Repeat("0", 3-len(format)) + format
+	}
+	constraints := 1
+	if len(fields) > 3 {
+		if constraints, err = strconv.Atoi(fields[3]); err != nil || constraints < 1 {
+			return nil, reader.lines.errorf("invalid ncon %q", fields[3])
+		}
+	}
+
+	if format[0] == '1' {
+		reader.vertexValues++
+	}
+	if format[1] == '1' {
+		reader.vertexValues += constraints
+	}
+	reader.header.Weighted = format[2] == '1'
+
+	return reader, nil
+}
+
+func (r *metisReader) Header() Header {
+	return r.header
+}
+
+func (r *metisReader) Read() (Edge, error) {
+	for {
+		if edge, ok, err := r.nextNeighbor(); ok || err != nil {
+			return edge, err
+		}
+
+		// Empty lines are vertices without neighbours, so only comments are skipped
+		line, err := r.lines.next()
+		for err == nil && strings.HasPrefix(line, "%") {
+			line, err = r.lines.next()
+		}
+		if err == io.EOF {
+			if r.vertex+1 != r.header.Vertices {
+				return Edge{}, fmt.Errorf("%s header declares %d vertices, found %d", METIS, r.header.Vertices, r.vertex+1)
+			}
+			if err := checkCount(METIS, r.header.Edges, r.read); err != nil {
+				return Edge{}, err
+			}
+			return Edge{}, io.EOF
+		}
+		if err != nil {
+			return Edge{}, err
+		}
+
+		if r.vertex+1 >= r.header.Vertices {
+			if line == "" {
+				continue
+			}
+			return Edge{}, r.lines.errorf("more vertices than the %d declared", r.header.Vertices)
+		}
+		r.vertex++
+
+		fields := strings.Fields(line)
+		if len(fields) < r.vertexValues {
+			return Edge{}, r.lines.errorf("expected %d vertex values, got %d fields", r.vertexValues, len(fields))
+		}
+		r.neighbors = fields[r.vertexValues:]
+		if r.header.Weighted && len(r.neighbors)%2 != 0 {
+			return Edge{}, r.lines.errorf("expected pairs of neighbour and edge weight")
+		}
+	}
+}
+
+// nextNeighbor returns the next edge of the current vertex leading to a vertex after it
+func (r *metisReader) nextNeighbor() (Edge, bool, error) {
+	for len(r.neighbors) > 0 {
+		edge := Edge{Source: r.vertex, Weight: 1}
+		var err error
+		if edge.Target, err = parseVertex(r.neighbors[0], 1, r.header.Vertices); err != nil {
+			return Edge{}, false, r.lines.errorf("%v", err)
+		}
+		if r.header.Weighted {
+			if edge.Weight, err = parseWeight(r.neighbors[1]); err != nil {
+				return Edge{}, false, r.lines.errorf("%v", err)
+			}
+			r.neighbors = r.neighbors[2:]
+		} else {
+			r.neighbors = r.neighbors[1:]
+		}
+
+		if edge.Target == edge.Source {
+			return Edge{}, false, r.lines.errorf("METIS graphs have no self loops")
+		}
+		if edge.Target > edge.Source {
+			r.read++
+			return edge, true, nil
+		}
+	}
+	return Edge{}, false, nil
+}
+
+type metisNeighbor struct {
+	vertex int64
+	weight float64
+}
+
+// metisWriter holds the graph until Close, since every vertex lists all of its neighbours
+type metisWriter struct {
+	w         io.Writer
+	header    Header
+	neighbors map[int64][]metisNeighbor
+	edges     int64
+	vertices  int64
+}
+
+func newMETISWriter(w io.Writer, header Header) (*metisWriter, error) {
+	if header.Directed {
+		return nil, fmt.Errorf("%s holds undirected graphs only", METIS)
+	}
+	return &metisWriter{w: w, header: header, neighbors: map[int64][]metisNeighbor{}, vertices: header.Vertices}, nil
+}
+
+func (w *metisWriter) Write(edge Edge) error {
+	if edge.Source == edge.Target {
+		return fmt.Errorf("%s graphs have no self loops, got one at vertex %d", METIS, edge.Source)
+	}
+
+	w.neighbors[edge.Source] = append(w.neighbors[edge.Source], metisNeighbor{edge.Target, edge.Weight})
+	w.neighbors[edge.Target] = append(w.neighbors[edge.Target], metisNeighbor{edge.Source, edge.Weight})
+	w.edges++
+	for _, vertex := range []int64{edge.Source, edge.Target} {
+		if vertex >= w.vertices {
+			w.vertices = vertex + 1
+		}
+	}
+	return nil
+}
+
+func (w *metisWriter) Close() error {
+	if w.header.Edges > 0 {
+		if err := checkCount(METIS, w.header.Edges, w.edges); err != nil {
+			return err
+		}
+	}
+
+	out := bufio.NewWriter(w.w)
+	header := fmt.Sprintf("%d %d", w.vertices, w.edges)
+	if w.header.Weighted {
+		header += " 001"
+	}
+	if _, err := out.WriteString(header + "\n"); err != nil {
+		return err
+	}
+
+	for vertex := int64(0); vertex < w.vertices; vertex++ {
+		neighbors := w.neighbors[vertex]
+		sort.SliceStable(neighbors, func(i, j int) bool {
+			return neighbors[i].vertex < neighbors[j].vertex
+		})
+
+		fields := make([]string, 0, 2*len(neighbors))
+		for _, neighbor := range neighbors {
+			fields = append(fields, formatVertex(neighbor.vertex, 1))
+			if w.header.Weighted {
+				fields = append(fields, formatWeight(neighbor.weight))
+			}
+		}
+		if _, err := out.WriteString(strings.Join(fields, " ") + "\n"); err != nil {
+			return err
+		}
+	}
+	return out.Flush()
+}
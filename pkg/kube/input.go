@@ -0,0 +1,85 @@
+package kube
+
+import (
+	"context"
+	"encoding/json"
+
+	upcxxv1alpha1types "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+)
+
+// Key of the input graph in the input ConfigMap of a computation
+const InputKey = "graph.txt"
+
+// Suffix of the ConfigMap holding the input graph of a computation
+const inputSuffix = "-input"
+
+func buildInputConfigMapName(name string) string {
+	return name + inputSuffix
+}
+
+// newInputSpec points the UPCXX to the ConfigMap created by createInputConfigMap
+func newInputSpec(name string, format string, directed bool) *upcxxv1alpha1types.InputSpec {
+	return &upcxxv1alpha1types.InputSpec{
+		ConfigMap: core.ConfigMapKeySelector{
+			LocalObjectReference: core.LocalObjectReference{Name: buildInputConfigMapName(name)},
+			Key:                  InputKey,
+		},
+		Format:   format,
+		Directed: directed,
+	}
+}
+
+// createInputConfigMap stores the input graph of a computation. It is created before the UPCXX,
+// so that the operator finds it as soon as it sees the UPCXX, and owned by the UPCXX afterwards
+// by setInputConfigMapOwner.
+func createInputConfigMap(name string, input []byte) error {
+	configMap := &core.ConfigMap{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      buildInputConfigMapName(name),
+			Namespace: Namespace,
+			Labels: map[string]string{
+				upcxxv1alpha1types.UPCXXLabel: upcxxv1alpha1types.UPCXXLabelValue(name),
+			},
+		},
+		Data: map[string]string{
+			InputKey: string(input),
+		},
+	}
+
+	_, err := createCoreClient().CoreV1().ConfigMaps(Namespace).Create(context.TODO(), configMap, metav1.CreateOptions{})
+	return err
+}
+
+// setInputConfigMapOwner makes the UPCXX the owner of its input ConfigMap, so it is deleted
+// along with it
+func setInputConfigMapOwner(upcxx *upcxxv1alpha1types.UPCXX) error {
+	isController := true
+	patch, err := json.Marshal(map[string]interface{}{
+		"metadata": map[string]interface{}{
+			"ownerReferences": []metav1.OwnerReference{
+				{
+					APIVersion: upcxxv1alpha1types.GroupVersion.String(),
+					Kind:       "UPCXX",
+					Name:       upcxx.Name,
+					UID:        upcxx.UID,
+					Controller: &isController,
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	_, err = createCoreClient().CoreV1().ConfigMaps(upcxx.Namespace).Patch(context.TODO(),
+		buildInputConfigMapName(upcxx.Name), types.MergePatchType, patch, metav1.PatchOptions{})
+	return err
+}
+
+// deleteInputConfigMap deletes the input of a computation that could not be created
+func deleteInputConfigMap(name string) error {
+	return createCoreClient().CoreV1().ConfigMaps(Namespace).Delete(context.TODO(), buildInputConfigMapName(name), metav1.DeleteOptions{})
+}
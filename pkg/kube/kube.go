@@ -4,11 +4,14 @@ import (
 	"flag"
 	"log"
 	"path/filepath"
+	"strings"
 
 	glconst "github.com/lnikon/glfs-pkg/pkg/constants"
 	upcxxv1alpha1types "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
 	upcxxv1alpha1clientset "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/clientset/v1alpha1"
+	upcxxcontrollers "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/controllers"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"k8s.io/client-go/util/homedir"
 	ctrl "sigs.k8s.io/controller-runtime"
 	// meta "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -42,7 +45,7 @@ func createUpcxxClient() upcxxv1alpha1clientset.UPCXXInterface {
 		log.Fatal(err.Error())
 	}
 
-	return clientset.UPCXX("default")
+	return clientset.UPCXX(Namespace)
 }
 
 // TODO: Review
@@ -73,9 +76,33 @@ func createUpcxxClient() upcxxv1alpha1clientset.UPCXXInterface {
 //	return len(pods.Items)
 //}
 
-func CreateUPCXX(name string) error {
-	upcxxClient := createUpcxxClient()
+// UPCXXOptions holds the user supplied parts of a new UPCXX resource
+type UPCXXOptions struct {
+	Algorithm   glconst.Algorithm
+	Parameters  map[string]string
+	Owner       string
+	WorkerCount int32
+	Priority    int32
+	RetainData  bool
+
+	// Verify the result against the reference implementation, the job then gets Shared storage
+	Verify *upcxxv1alpha1types.VerifySpec
+
+	// Input graph of the job in InputFormat, stored in a ConfigMap owned by the UPCXX. The
+	// result of a verified job is checked against it.
+	Input         []byte
+	InputFormat   string
+	InputDirected bool
+}
+
+// Namespace the computations and their pods live in
+const Namespace = "default"
 
+// Worker count of a UPCXX job when none is requested
+const DefaultWorkerCount = 2
+
+// newUPCXX builds the UPCXX resource for a new computation
+func newUPCXX(name string, opts UPCXXOptions) *upcxxv1alpha1types.UPCXX {
 	groupVersionKind := schema.GroupVersionKind{}
 	groupVersionKind.Group = upcxxv1alpha1types.GroupVersion.Group
 	groupVersionKind.Version = upcxxv1alpha1types.GroupVersion.Version
@@ -83,7 +110,9 @@ func CreateUPCXX(name string) error {
 
 	apiVersion, kind := groupVersionKind.ToAPIVersionAndKind()
 
-	log.Default().Printf("%v\n", groupVersionKind)
+	if opts.WorkerCount == 0 {
+		opts.WorkerCount = DefaultWorkerCount
+	}
 
 	upcxx := &upcxxv1alpha1types.UPCXX{
 		TypeMeta: metav1.TypeMeta{
@@ -92,18 +121,101 @@ func CreateUPCXX(name string) error {
 		},
 		ObjectMeta: metav1.ObjectMeta{
 			Name:      name,
-			Namespace: "default",
+			Namespace: Namespace,
 		},
 		Spec: upcxxv1alpha1types.UPCXXSpec{
-			StatefulSetName: name,
-			WorkerCount:     2,
-			Algorithm:       glconst.Kruskal,
+			WorkerCount: opts.WorkerCount,
+			Priority:    opts.Priority,
+			RetainData:  opts.RetainData,
+			Algorithm:   opts.Algorithm,
+			Parameters:  opts.Parameters,
 		},
 		Status: upcxxv1alpha1types.UPCXXStatus{},
 	}
 
-	upcxx, err := upcxxClient.Create(upcxx)
-	return err
+	if opts.Verify != nil {
+		upcxx.Spec.Verify = opts.Verify
+		upcxx.Spec.Storage = &upcxxv1alpha1types.StorageSpec{Mode: upcxxv1alpha1types.SharedStorage}
+	}
+
+	if opts.Input != nil {
+		upcxx.Spec.Input = newInputSpec(name, opts.InputFormat, opts.InputDirected)
+	}
+
+	if opts.Owner != "" {
+		upcxx.ObjectMeta.Labels = map[string]string{
+			upcxxv1alpha1types.OwnerLabel: OwnerLabelValue(opts.Owner),
+		}
+		upcxx.ObjectMeta.Annotations = map[string]string{
+			upcxxv1alpha1types.OwnerAnnotation: opts.Owner,
+		}
+	}
+
+	return upcxx
+}
+
+func CreateUPCXX(name string, opts UPCXXOptions) error {
+	upcxxClient := createUpcxxClient()
+
+	upcxx := newUPCXX(name, opts)
+	log.Default().Printf("%v\n", upcxx.GroupVersionKind())
+
+	if opts.Input != nil {
+		if err := createInputConfigMap(name, opts.Input); err != nil {
+			return err
+		}
+	}
+
+	created, err := upcxxClient.Create(upcxx)
+	if err != nil {
+		if opts.Input != nil {
+			if err := deleteInputConfigMap(name); err != nil {
+				log.Default().Printf("Unable to delete the input of %s: %v\n", name, err)
+			}
+		}
+		return err
+	}
+	if opts.Input == nil {
+		return nil
+	}
+
+	// Neither is of any use without the other, so both are deleted when the input can not be
+	// owned by the UPCXX
+	if err := setInputConfigMapOwner(created); err != nil {
+		if err := DeleteDeployment(name); err != nil {
+			log.Default().Printf("Unable to delete %s: %v\n", name, err)
+		}
+		if err := deleteInputConfigMap(name); err != nil {
+			log.Default().Printf("Unable to delete the input of %s: %v\n", name, err)
+		}
+		return err
+	}
+
+	return nil
+}
+
+// RenderUPCXX renders the objects the operator would create for a new computation as YAML,
+// using the same builders as the operator. The cluster is not touched.
+func RenderUPCXX(name string, opts UPCXXOptions) ([]byte, error) {
+	return upcxxcontrollers.RenderYAML(newUPCXX(name, opts))
+}
+
+// OwnerLabelValue converts a user name into a valid label value:
+// at most 63 characters of alphanumerics, '-', '_' or '.', starting and ending with an alphanumeric.
+func OwnerLabelValue(owner string) string {
+	value := []byte(owner)
+	for i, c := range value {
+		isAlnum := (c >= 'a' && c <= 'z') || (c >= 'A' && c <= 'Z') || (c >= '0' && c <= '9')
+		if !isAlnum && c != '-' && c != '_' && c != '.' {
+			value[i] = '_'
+		}
+	}
+
+	if len(value) > validation.LabelValueMaxLength {
+		value = value[:validation.LabelValueMaxLength]
+	}
+
+	return strings.Trim(string(value), "-_.")
 }
 
 func GetDeployment(name string) *upcxxv1alpha1types.UPCXX {
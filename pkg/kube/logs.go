@@ -0,0 +1,198 @@
+package kube
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"io"
+	"log"
+	"strings"
+	"sync"
+
+	upcxxv1alpha1types "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+	core "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/labels"
+	"k8s.io/client-go/kubernetes"
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Roles of the pods that make up a single UPCXX computation
+const (
+	LauncherRole = "launcher"
+	WorkerRole   = "worker"
+
+	// Runs the reference implementation once the computation succeeded, when it is verified
+	VerifierRole = "verifier"
+)
+
+// The upcxx-operator appends these suffixes to the names of the launcher, worker and verifier
+// objects. The suffixes are kept intact when the rest of the name is shortened.
+const (
+	launcherSuffix = "-" + LauncherRole
+	workerSuffix   = "-" + WorkerRole
+	verifierSuffix = "-verify"
+)
+
+// Longest log line passed through when the logs of several pods are merged
+const maxLogLineSize = 1024 * 1024
+
+// LogOptions selects the pods of a computation to read logs from.
+// When neither Pod nor Role is set, logs of all pods are returned.
+type LogOptions struct {
+	Pod          string
+	Role         string
+	Follow       bool
+	TailLines    *int64
+	SinceSeconds *int64
+}
+
+func createCoreClient() kubernetes.Interface {
+	config := ctrl.GetConfigOrDie()
+	clientset, err := kubernetes.NewForConfig(config)
+	if err != nil {
+		log.Fatal(err.Error())
+	}
+
+	return clientset
+}
+
+// GetComputationLogs streams container logs of the computation pods until ctx is done.
+// Logs of multiple pods are merged line by line, each line prefixed by the pod name.
+func GetComputationLogs(ctx context.Context, name string, opts LogOptions) (io.ReadCloser, error) {
+	pods, err := getComputationPods(ctx, name, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	podClient := createCoreClient().CoreV1().Pods(Namespace)
+	podLogOptions := &core.PodLogOptions{
+		Follow:       opts.Follow,
+		TailLines:    opts.TailLines,
+		SinceSeconds: opts.SinceSeconds,
+	}
+
+	streams := make(map[string]io.ReadCloser, len(pods))
+	for _, pod := range pods {
+		stream, err := podClient.GetLogs(pod, podLogOptions).Stream(ctx)
+		if err != nil {
+			for _, s := range streams {
+				s.Close()
+			}
+			return nil, fmt.Errorf("streaming logs of pod %s: %w", pod, err)
+		}
+		streams[pod] = stream
+	}
+
+	if len(streams) == 1 {
+		return streams[pods[0]], nil
+	}
+
+	return mergeLogStreams(streams), nil
+}
+
+func getComputationPods(ctx context.Context, name string, opts LogOptions) ([]string, error) {
+	var suffixes []string
+	switch opts.Role {
+	case LauncherRole:
+		suffixes = []string{launcherSuffix}
+	case WorkerRole:
+		suffixes = []string{workerSuffix}
+	case VerifierRole:
+		suffixes = []string{verifierSuffix}
+	case "":
+		suffixes = []string{launcherSuffix, workerSuffix, verifierSuffix}
+	default:
+		return nil, fmt.Errorf("unknown pod role %q", opts.Role)
+	}
+
+	// Child names may be shortened by the operator, so pods are selected through the label
+	// holding the UPCXX and told apart by the suffix of their app label
+	selector := labels.SelectorFromSet(labels.Set{
+		"hpc":                         "upcxx",
+		upcxxv1alpha1types.UPCXXLabel: upcxxv1alpha1types.UPCXXLabelValue(name),
+	})
+	podList, err := createCoreClient().CoreV1().Pods(Namespace).List(ctx, metav1.ListOptions{
+		LabelSelector: selector.String(),
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	var pods []string
+	for _, pod := range podList.Items {
+		if !hasAnySuffix(pod.Labels["app"], suffixes) {
+			continue
+		}
+		if opts.Pod == "" || opts.Pod == pod.Name {
+			pods = append(pods, pod.Name)
+		}
+	}
+
+	if len(pods) == 0 {
+		if opts.Pod != "" {
+			return nil, fmt.Errorf("pod %s does not belong to computation %s", opts.Pod, name)
+		}
+		return nil, fmt.Errorf("no pods found for computation %s", name)
+	}
+
+	return pods, nil
+}
+
+func hasAnySuffix(value string, suffixes []string) bool {
+	for _, suffix := range suffixes {
+		if strings.HasSuffix(value, suffix) {
+			return true
+		}
+	}
+	return false
+}
+
+type mergedLogStream struct {
+	*io.PipeReader
+	streams map[string]io.ReadCloser
+}
+
+func (m *mergedLogStream) Close() error {
+	for _, stream := range m.streams {
+		stream.Close()
+	}
+	return m.PipeReader.Close()
+}
+
+func mergeLogStreams(streams map[string]io.ReadCloser) io.ReadCloser {
+	reader, writer := io.Pipe()
+
+	var mu sync.Mutex
+	var wg sync.WaitGroup
+	for pod, stream := range streams {
+		wg.Add(1)
+		go func(pod string, stream io.Reader) {
+			defer wg.Done()
+			scanner := bufio.NewScanner(stream)
+			scanner.Buffer(make([]byte, 0, 64*1024), maxLogLineSize)
+			for scanner.Scan() {
+				mu.Lock()
+				_, err := fmt.Fprintf(writer, "[%s] %s\n", pod, scanner.Text())
+				mu.Unlock()
+				if err != nil {
+					return
+				}
+			}
+
+			// The other pods keep streaming, so the error is reported in place of the rest of the logs
+			if err := scanner.Err(); err != nil {
+				mu.Lock()
+				fmt.Fprintf(writer, "[%s] error reading logs: %v\n", pod, err)
+				mu.Unlock()
+			}
+		}(pod, stream)
+	}
+
+	go func() {
+		wg.Wait()
+		writer.Close()
+	}()
+
+	return &mergedLogStream{PipeReader: reader, streams: streams}
+}
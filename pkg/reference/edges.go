@@ -0,0 +1,88 @@
+// Package reference holds sequential implementations of the algorithms run by the UPC++ jobs.
+// They are meant for verifying the results of small jobs, not for speed.
+package reference
+
+import "sort"
+
+// Edge is an edge of the input graph, read by the graph package. Undirected graphs list every
+// edge once.
+type Edge struct {
+	Source int64
+	Target int64
+	Weight float64
+}
+
+// TotalWeight sums up the weights of the edges
+func TotalWeight(edges []Edge) float64 {
+	total := 0.0
+	for _, edge := range edges {
+		total += edge.Weight
+	}
+	return total
+}
+
+type arc struct {
+	target int64
+	weight float64
+}
+
+// graph is the adjacency list of the input, following undirected edges both ways
+type graph struct {
+	vertices []int64
+	arcs     map[int64][]arc
+}
+
+func newGraph(edges []Edge, directed bool) *graph {
+	g := &graph{arcs: map[int64][]arc{}}
+	for _, edge := range edges {
+		g.addVertex(edge.Source)
+		g.addVertex(edge.Target)
+		g.arcs[edge.Source] = append(g.arcs[edge.Source], arc{edge.Target, edge.Weight})
+		if !directed && edge.Source != edge.Target {
+			g.arcs[edge.Target] = append(g.arcs[edge.Target], arc{edge.Source, edge.Weight})
+		}
+	}
+	sort.Slice(g.vertices, func(i, j int) bool {
+		return g.vertices[i] < g.vertices[j]
+	})
+	return g
+}
+
+func (g *graph) addVertex(vertex int64) {
+	if _, ok := g.arcs[vertex]; !ok {
+		g.arcs[vertex] = nil
+		g.vertices = append(g.vertices, vertex)
+	}
+}
+
+func (g *graph) hasVertex(vertex int64) bool {
+	_, ok := g.arcs[vertex]
+	return ok
+}
+
+// disjointSets is a union-find over vertex ids
+type disjointSets map[int64]int64
+
+func (s disjointSets) find(vertex int64) int64 {
+	parent, ok := s[vertex]
+	if !ok || parent == vertex {
+		return vertex
+	}
+	root := s.find(parent)
+	s[vertex] = root
+	return root
+}
+
+// union merges the sets of both vertices, keeping the smaller root. It reports false when they
+// already were in the same set.
+func (s disjointSets) union(a, b int64) bool {
+	rootA, rootB := s.find(a), s.find(b)
+	if rootA == rootB {
+		return false
+	}
+	if rootB < rootA {
+		rootA, rootB = rootB, rootA
+	}
+	s[rootB] = rootA
+	return true
+}
@@ -0,0 +1,7 @@
+module github.com/lnikon/glfs-pkg/pkg/reference
+
+go 1.17
+
+require github.com/lnikon/glfs-pkg/pkg/constants v0.0.0-20211103152516-cac955b50b84
+
+replace github.com/lnikon/glfs-pkg/pkg/constants => ../constants
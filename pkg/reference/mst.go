@@ -0,0 +1,72 @@
+package reference
+
+import (
+	"container/heap"
+	"sort"
+)
+
+// Kruskal returns a minimum spanning forest of the undirected graph. Edges of equal weight are
+// taken in input order.
+func Kruskal(edges []Edge) []Edge {
+	sorted := make([]Edge, len(edges))
+	copy(sorted, edges)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		return sorted[i].Weight < sorted[j].Weight
+	})
+
+	sets := disjointSets{}
+	var forest []Edge
+	for _, edge := range sorted {
+		if sets.union(edge.Source, edge.Target) {
+			forest = append(forest, edge)
+		}
+	}
+	return forest
+}
+
+// Prim returns a minimum spanning tree of the component of root in the undirected graph.
+// The tree is empty when root is not a vertex of the graph.
+func Prim(edges []Edge, root int64) []Edge {
+	g := newGraph(edges, false)
+	if !g.hasVertex(root) {
+		return nil
+	}
+
+	visited := map[int64]bool{root: true}
+	frontier := &edgeHeap{}
+	for _, a := range g.arcs[root] {
+		heap.Push(frontier, Edge{root, a.target, a.weight})
+	}
+
+	var tree []Edge
+	for frontier.Len() > 0 {
+		edge := heap.Pop(frontier).(Edge)
+		if visited[edge.Target] {
+			continue
+		}
+		visited[edge.Target] = true
+		tree = append(tree, edge)
+
+		for _, a := range g.arcs[edge.Target] {
+			if !visited[a.target] {
+				heap.Push(frontier, Edge{edge.Target, a.target, a.weight})
+			}
+		}
+	}
+	return tree
+}
+
+// edgeHeap orders edges by weight, lightest first
+type edgeHeap []Edge
+
+func (h edgeHeap) Len() int            { return len(h) }
+func (h edgeHeap) Less(i, j int) bool  { return h[i].Weight < h[j].Weight }
+func (h edgeHeap) Swap(i, j int)       { h[i], h[j] = h[j], h[i] }
+func (h *edgeHeap) Push(x interface{}) { *h = append(*h, x.(Edge)) }
+
+func (h *edgeHeap) Pop() interface{} {
+	old := *h
+	edge := old[len(old)-1]
+	*h = old[:len(old)-1]
+	return edge
+}
@@ -0,0 +1,51 @@
+package reference
+
+import "math"
+
+// PageRank computes the ranks of the vertices by power iteration. Every vertex passes its rank
+// along its outgoing edges in proportion to their weights, vertices without outgoing edges
+// spread it over all vertices. Iteration stops once the ranks change by less than tolerance in
+// total, or after the given number of iterations.
+func PageRank(edges []Edge, directed bool, damping, tolerance float64, iterations int) map[int64]float64 {
+	g := newGraph(edges, directed)
+	ranks := map[int64]float64{}
+	if len(g.vertices) == 0 {
+		return ranks
+	}
+
+	n := float64(len(g.vertices))
+	outWeights := map[int64]float64{}
+	for _, vertex := range g.vertices {
+		ranks[vertex] = 1 / n
+		for _, a := range g.arcs[vertex] {
+			outWeights[vertex] += a.weight
+		}
+	}
+
+	for i := 0; i < iterations; i++ {
+		dangling := 0.0
+		next := make(map[int64]float64, len(ranks))
+		for _, vertex := range g.vertices {
+			if outWeights[vertex] <= 0 {
+				dangling += ranks[vertex]
+				continue
+			}
+			for _, a := range g.arcs[vertex] {
+				next[a.target] += ranks[vertex] * a.weight / outWeights[vertex]
+			}
+		}
+
+		change := 0.0
+		for _, vertex := range g.vertices {
+			rank := (1-damping)/n + damping*(next[vertex]+dangling/n)
+			change += math.Abs(rank - ranks[vertex])
+			next[vertex] = rank
+		}
+		ranks = next
+
+		if change < tolerance {
+			break
+		}
+	}
+	return ranks
+}
@@ -0,0 +1,199 @@
+package reference
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+)
+
+// Two triangles joined by the edge 2-3, the second one with a tie between 3-5 and 4-5
+var sampleEdges = []Edge{
+	{0, 1, 1},
+	{1, 2, 2},
+	{0, 2, 3},
+	{2, 3, 4},
+	{3, 4, 1},
+	{4, 5, 2},
+	{3, 5, 2},
+}
+
+// readSample returns a copy of the sample edges, free to be modified by the test
+func readSample(t *testing.T) []Edge {
+	return append([]Edge(nil), sampleEdges...)
+}
+
+func TestSpanningTrees(t *testing.T) {
+	edges := readSample(t)
+
+	kruskal := Kruskal(edges)
+	if len(kruskal) != 5 || TotalWeight(kruskal) != 10 {
+		t.Errorf("Kruskal returned %v, want 5 edges weighing 10", kruskal)
+	}
+
+	for _, root := range []int64{0, 3, 5} {
+		prim := Prim(edges, root)
+		if len(prim) != 5 || TotalWeight(prim) != 10 {
+			t.Errorf("Prim from %d returned %v, want 5 edges weighing 10", root, prim)
+		}
+	}
+
+	forest := Kruskal(append(edges, Edge{7, 8, 1}))
+	if len(forest) != 6 || TotalWeight(forest) != 11 {
+		t.Errorf("Kruskal of a disconnected graph returned %v, want 6 edges weighing 11", forest)
+	}
+	if tree := Prim(edges, 42); len(tree) != 0 {
+		t.Errorf("Prim from a missing root returned %v", tree)
+	}
+}
+
+func TestTraversals(t *testing.T) {
+	edges := readSample(t)
+
+	levels := BFS(edges, 0, false)
+	if levels[5] != 3 || len(levels) != 6 {
+		t.Errorf("BFS returned %v", levels)
+	}
+	if levels := BFS([]Edge{{0, 1, 1}, {2, 1, 1}}, 0, true); len(levels) != 2 {
+		t.Errorf("directed BFS returned %v", levels)
+	}
+
+	distances, err := ShortestPaths(edges, 0, false)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if distances[2] != 3 || distances[5] != 9 {
+		t.Errorf("ShortestPaths returned %v", distances)
+	}
+	if _, err := ShortestPaths([]Edge{{0, 1, -1}}, 0, false); err == nil {
+		t.Error("expected an error for a negative weight")
+	}
+
+	components := ConnectedComponents(append(edges, Edge{9, 7, 1}))
+	if components[5] != 0 || components[9] != 7 {
+		t.Errorf("ConnectedComponents returned %v", components)
+	}
+}
+
+func TestPageRank(t *testing.T) {
+	ranks := PageRank(readSample(t), false, 0.85, 1e-9, 1000)
+
+	total := 0.0
+	for _, rank := range ranks {
+		total += rank
+	}
+	if math.Abs(total-1) > 1e-9 {
+		t.Errorf("ranks sum up to %v", total)
+	}
+	if !(ranks[2] > ranks[0] && ranks[3] > ranks[5]) {
+		t.Errorf("vertices with more edges should rank higher: %v", ranks)
+	}
+}
+
+func TestVerify(t *testing.T) {
+	edges := readSample(t)
+
+	tests := []struct {
+		name      string
+		algorithm glconstants.Algorithm
+		params    map[string]string
+		result    string
+		verdict   Verdict
+	}{
+		{
+			name:      "kruskal",
+			algorithm: glconstants.Kruskal,
+			result:    "0 1 1\n1 2 2\n2 3 4\n3 4 1\n4 5 2\n",
+			verdict:   Passed,
+		},
+		{
+			name:      "kruskal with a tied edge",
+			algorithm: glconstants.Kruskal,
+			result:    "0 1 1\n1 2 2\n2 3 4\n3 4 1\n5 3 2\n",
+			verdict:   Passed,
+		},
+		{
+			name:      "kruskal too heavy",
+			algorithm: glconstants.Kruskal,
+			result:    "0 1 1\n0 2 3\n2 3 4\n3 4 1\n4 5 2\n",
+			verdict:   Failed,
+		},
+		{
+			name:      "kruskal with a cycle",
+			algorithm: glconstants.Kruskal,
+			result:    "0 1 1\n1 2 2\n0 2 3\n3 4 1\n4 5 2\n",
+			verdict:   Failed,
+		},
+		{
+			name:      "prim with an edge missing in the input",
+			algorithm: glconstants.Prim,
+			params:    map[string]string{"root": "3"},
+			result:    "0 1 1\n1 2 2\n2 3 4\n3 4 1\n0 5 1\n",
+			verdict:   Failed,
+		},
+		{
+			name:      "malformed result",
+			algorithm: glconstants.Prim,
+			result:    "0 1\n",
+			verdict:   Failed,
+		},
+		{
+			name:      "bfs",
+			algorithm: glconstants.BFS,
+			result:    "0 0 0\n1 1 0\n2 1 0\n3 2 2\n4 3 3\n5 3 3\n",
+			verdict:   Passed,
+		},
+		{
+			name:      "bfs with a wrong parent",
+			algorithm: glconstants.BFS,
+			result:    "0 0 0\n1 1 0\n2 1 0\n3 2 2\n4 3 3\n5 3 4\n",
+			verdict:   Failed,
+		},
+		{
+			name:      "sssp",
+			algorithm: glconstants.SSSP,
+			params:    map[string]string{"source": "5"},
+			result:    "5 0 5\n4 2 5\n3 2 5\n2 6 3\n1 8 2\n0 9 1\n",
+			verdict:   Passed,
+		},
+		{
+			name:      "sssp with a wrong distance",
+			algorithm: glconstants.SSSP,
+			params:    map[string]string{"source": "5"},
+			result:    "5 0 5\n4 2 5\n3 2 5\n2 6 3\n1 8 2\n0 9.5 1\n",
+			verdict:   Failed,
+		},
+		{
+			name:      "connected components",
+			algorithm: glconstants.ConnectedComponents,
+			result:    "0 0\n1 0\n2 0\n3 0\n4 0\n5 0\n",
+			verdict:   Passed,
+		},
+		{
+			name:      "connected components missing a vertex",
+			algorithm: glconstants.ConnectedComponents,
+			result:    "0 0\n1 0\n2 0\n3 0\n4 0\n",
+			verdict:   Failed,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			report, err := Verify(tt.algorithm, tt.params, false, edges, strings.NewReader(tt.result))
+			if err != nil {
+				t.Fatal(err)
+			}
+			if report.Verdict != tt.verdict {
+				t.Errorf("got %s (%s), want %s", report.Verdict, report.Message, tt.verdict)
+			}
+		})
+	}
+
+	if _, err := Verify("unknown", nil, false, edges, strings.NewReader("")); err == nil {
+		t.Error("expected an error for an unknown algorithm")
+	}
+	if _, err := Verify(glconstants.Prim, map[string]string{"root": "-1"}, false, edges, strings.NewReader("")); err == nil {
+		t.Error("expected an error for an invalid parameter")
+	}
+}
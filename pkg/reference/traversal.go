@@ -0,0 +1,79 @@
+package reference
+
+import (
+	"container/heap"
+	"fmt"
+)
+
+// BFS returns the number of edges on a shortest path from source to every reachable vertex.
+// Edge weights are ignored.
+func BFS(edges []Edge, source int64, directed bool) map[int64]int64 {
+	g := newGraph(edges, directed)
+	if !g.hasVertex(source) {
+		return map[int64]int64{}
+	}
+
+	levels := map[int64]int64{source: 0}
+	queue := []int64{source}
+	for len(queue) > 0 {
+		vertex := queue[0]
+		queue = queue[1:]
+
+		for _, a := range g.arcs[vertex] {
+			if _, ok := levels[a.target]; !ok {
+				levels[a.target] = levels[vertex] + 1
+				queue = append(queue, a.target)
+			}
+		}
+	}
+	return levels
+}
+
+// ShortestPaths returns the weight of a shortest path from source to every reachable vertex,
+// computed by Dijkstra's algorithm. Like delta-stepping it requires non-negative weights.
+func ShortestPaths(edges []Edge, source int64, directed bool) (map[int64]float64, error) {
+	for _, edge := range edges {
+		if edge.Weight < 0 {
+			return nil, fmt.Errorf("edge %d-%d has negative weight %v", edge.Source, edge.Target, edge.Weight)
+		}
+	}
+
+	g := newGraph(edges, directed)
+	distances := map[int64]float64{}
+	if !g.hasVertex(source) {
+		return distances, nil
+	}
+
+	// The heap holds tentative paths, the weight of each entry is the distance of its target
+	frontier := &edgeHeap{{Source: source, Target: source, Weight: 0}}
+	for frontier.Len() > 0 {
+		path := heap.Pop(frontier).(Edge)
+		if _, done := distances[path.Target]; done {
+			continue
+		}
+		distances[path.Target] = path.Weight
+
+		for _, a := range g.arcs[path.Target] {
+			if _, done := distances[a.target]; !done {
+				heap.Push(frontier, Edge{path.Target, a.target, path.Weight + a.weight})
+			}
+		}
+	}
+	return distances, nil
+}
+
+// ConnectedComponents labels every vertex of the undirected graph with the smallest vertex of
+// its component
+func ConnectedComponents(edges []Edge) map[int64]int64 {
+	sets := disjointSets{}
+	for _, edge := range edges {
+		sets.union(edge.Source, edge.Target)
+	}
+
+	components := map[int64]int64{}
+	for _, edge := range edges {
+		components[edge.Source] = sets.find(edge.Source)
+		components[edge.Target] = sets.find(edge.Target)
+	}
+	return components
+}
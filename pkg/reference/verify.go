@@ -0,0 +1,335 @@
+package reference
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+)
+
+// Verdict is the outcome of checking a result against the reference implementation
+type Verdict string
+
+const (
+	Passed  Verdict = "Passed"
+	Failed  Verdict = "Failed"
+	Skipped Verdict = "Skipped"
+)
+
+// Report is the outcome of a verification along with a human-readable explanation
+type Report struct {
+	Verdict Verdict `json:"verdict"`
+	Message string  `json:"message"`
+}
+
+// Ranks of PageRank may differ this much from the reference, since both stop iterating once
+// the ranks barely change
+const rankTolerance = 1e-4
+
+func passed(format string, args ...interface{}) Report {
+	return Report{Verdict: Passed, Message: fmt.Sprintf(format, args...)}
+}
+
+func failed(format string, args ...interface{}) Report {
+	return Report{Verdict: Failed, Message: fmt.Sprintf(format, args...)}
+}
+
+// Verify runs the reference implementation of the algorithm on the input and compares its
+// outcome with the result written by the job, in the format of the result schema of the
+// algorithm. Errors are returned for unknown algorithms and invalid parameters, problems of the
+// result itself make the verdict Failed.
+func Verify(algorithm glconstants.Algorithm, params map[string]string, directed bool, input []Edge, result io.Reader) (Report, error) {
+	spec, ok := glconstants.LookupAlgorithm(algorithm)
+	if !ok {
+		return Report{}, fmt.Errorf("unknown algorithm %s", algorithm)
+	}
+	params, err := spec.ValidateParameters(params)
+	if err != nil {
+		return Report{}, err
+	}
+
+	records, report, err := readResult(spec.Result, result)
+	if err != nil {
+		return Report{}, err
+	}
+	if report != nil {
+		return *report, nil
+	}
+
+	switch spec.Name {
+	case glconstants.Kruskal:
+		return verifySpanningTree(input, Kruskal(input), records), nil
+	case glconstants.Prim:
+		return verifySpanningTree(input, Prim(input, intField(params, "root")), records), nil
+	case glconstants.BFS:
+		return verifyBFS(input, intField(params, "source"), directed, records), nil
+	case glconstants.SSSP:
+		return verifyShortestPaths(input, intField(params, "source"), directed, records)
+	case glconstants.ConnectedComponents:
+		return verifyConnectedComponents(input, records), nil
+	case glconstants.PageRank:
+		ranks := PageRank(input, directed, floatField(params, "damping"), floatField(params, "tolerance"), int(intField(params, "iterations")))
+		return verifyPageRank(ranks, records), nil
+	}
+	return Report{}, fmt.Errorf("no reference implementation of %s", spec.Name)
+}
+
+// readResult parses the records of the result. Malformed records are reported as a failed
+// verification rather than an error.
+func readResult(schema glconstants.ResultSchema, result io.Reader) ([]map[string]string, *Report, error) {
+	var records []map[string]string
+
+	scanner := bufio.NewScanner(result)
+	for line := 1; scanner.Scan(); line++ {
+		text := strings.TrimSpace(scanner.Text())
+		if text == "" || text[0] == '#' {
+			continue
+		}
+
+		record, err := schema.ParseRecord(text)
+		if err != nil {
+			report := failed("result line %d: %v", line, err)
+			return nil, &report, nil
+		}
+		records = append(records, record)
+	}
+
+	return records, nil, scanner.Err()
+}
+
+func verifySpanningTree(input, expected []Edge, records []map[string]string) Report {
+	type key struct{ a, b int64 }
+	undirected := func(source, target int64) key {
+		if target < source {
+			source, target = target, source
+		}
+		return key{source, target}
+	}
+
+	weights := map[key][]float64{}
+	for _, edge := range input {
+		k := undirected(edge.Source, edge.Target)
+		weights[k] = append(weights[k], edge.Weight)
+	}
+
+	sets := disjointSets{}
+	var tree []Edge
+	for _, record := range records {
+		edge := Edge{intField(record, "source"), intField(record, "target"), floatField(record, "weight")}
+
+		found := false
+		for _, weight := range weights[undirected(edge.Source, edge.Target)] {
+			found = found || almostEqual(weight, edge.Weight, 0)
+		}
+		if !found {
+			return failed("edge %d-%d with weight %v is not in the input", edge.Source, edge.Target, edge.Weight)
+		}
+		if !sets.union(edge.Source, edge.Target) {
+			return failed("edge %d-%d closes a cycle", edge.Source, edge.Target)
+		}
+		tree = append(tree, edge)
+	}
+
+	if len(tree) != len(expected) {
+		return failed("result has %d edges, the reference tree has %d", len(tree), len(expected))
+	}
+	if !equalVertices(tree, expected) {
+		return failed("result spans other vertices than the reference tree")
+	}
+	if got, want := TotalWeight(tree), TotalWeight(expected); !almostEqual(got, want, 0) {
+		return failed("total weight is %v, the reference tree weighs %v", got, want)
+	}
+
+	inReference := map[key]bool{}
+	for _, edge := range expected {
+		inReference[undirected(edge.Source, edge.Target)] = true
+	}
+	differing := 0
+	for _, edge := range tree {
+		if !inReference[undirected(edge.Source, edge.Target)] {
+			differing++
+		}
+	}
+	if differing > 0 {
+		return passed("total weight %v matches, %d of %d edges differ from the reference tree through edges of equal weight", TotalWeight(tree), differing, len(tree))
+	}
+	return passed("total weight %v and all %d edges match the reference tree", TotalWeight(tree), len(tree))
+}
+
+func equalVertices(a, b []Edge) bool {
+	vertices := map[int64]int{}
+	for _, edge := range a {
+		vertices[edge.Source] |= 1
+		vertices[edge.Target] |= 1
+	}
+	for _, edge := range b {
+		vertices[edge.Source] |= 2
+		vertices[edge.Target] |= 2
+	}
+	for _, in := range vertices {
+		if in != 3 {
+			return false
+		}
+	}
+	return true
+}
+
+// vertexRecords indexes the records of a vertex result by vertex and checks that exactly the
+// expected vertices are present
+func vertexRecords(records []map[string]string, expected int) (map[int64]map[string]string, *Report) {
+	byVertex := map[int64]map[string]string{}
+	for _, record := range records {
+		vertex := intField(record, "vertex")
+		if _, ok := byVertex[vertex]; ok {
+			report := failed("vertex %d is listed more than once", vertex)
+			return nil, &report
+		}
+		byVertex[vertex] = record
+	}
+
+	if len(byVertex) != expected {
+		report := failed("result lists %d vertices, the reference reaches %d", len(byVertex), expected)
+		return nil, &report
+	}
+	return byVertex, nil
+}
+
+func verifyBFS(input []Edge, source int64, directed bool, records []map[string]string) Report {
+	levels := BFS(input, source, directed)
+	byVertex, report := vertexRecords(records, len(levels))
+	if report != nil {
+		return *report
+	}
+
+	g := newGraph(input, directed)
+	for vertex, record := range byVertex {
+		want, ok := levels[vertex]
+		if !ok {
+			return failed("vertex %d is not reachable from %d", vertex, source)
+		}
+		if got := intField(record, "level"); got != want {
+			return failed("vertex %d has level %d, the reference %d", vertex, got, want)
+		}
+
+		parent := intField(record, "parent")
+		if vertex == source {
+			if parent != source {
+				return failed("source %d has parent %d", source, parent)
+			}
+			continue
+		}
+		parentLevel, reached := levels[parent]
+		if !reached || parentLevel != want-1 || !g.hasArc(parent, vertex, func(float64) bool { return true }) {
+			return failed("vertex %d has parent %d, which is not a predecessor on a shortest path", vertex, parent)
+		}
+	}
+	return passed("levels and parents of all %d reached vertices match", len(levels))
+}
+
+func verifyShortestPaths(input []Edge, source int64, directed bool, records []map[string]string) (Report, error) {
+	distances, err := ShortestPaths(input, source, directed)
+	if err != nil {
+		return Report{}, err
+	}
+	byVertex, report := vertexRecords(records, len(distances))
+	if report != nil {
+		return *report, nil
+	}
+
+	g := newGraph(input, directed)
+	for vertex, record := range byVertex {
+		want, ok := distances[vertex]
+		if !ok {
+			return failed("vertex %d is not reachable from %d", vertex, source), nil
+		}
+		if got := floatField(record, "distance"); !almostEqual(got, want, 0) {
+			return failed("vertex %d has distance %v, the reference %v", vertex, got, want), nil
+		}
+
+		parent := intField(record, "parent")
+		if vertex == source {
+			if parent != source {
+				return failed("source %d has parent %d", source, parent), nil
+			}
+			continue
+		}
+		onShortestPath := func(weight float64) bool {
+			return almostEqual(distances[parent]+weight, want, 0)
+		}
+		if _, ok := distances[parent]; !ok || !g.hasArc(parent, vertex, onShortestPath) {
+			return failed("vertex %d has parent %d, which is not a predecessor on a shortest path", vertex, parent), nil
+		}
+	}
+	return passed("distances and parents of all %d reached vertices match", len(distances)), nil
+}
+
+func verifyConnectedComponents(input []Edge, records []map[string]string) Report {
+	components := ConnectedComponents(input)
+	byVertex, report := vertexRecords(records, len(components))
+	if report != nil {
+		return *report
+	}
+
+	for vertex, record := range byVertex {
+		want, ok := components[vertex]
+		if !ok {
+			return failed("vertex %d is not in the input", vertex)
+		}
+		if got := intField(record, "component"); got != want {
+			return failed("vertex %d is in component %d, the reference puts it in %d", vertex, got, want)
+		}
+	}
+	return passed("components of all %d vertices match", len(components))
+}
+
+func verifyPageRank(ranks map[int64]float64, records []map[string]string) Report {
+	byVertex, report := vertexRecords(records, len(ranks))
+	if report != nil {
+		return *report
+	}
+
+	for vertex, record := range byVertex {
+		want, ok := ranks[vertex]
+		if !ok {
+			return failed("vertex %d is not in the input", vertex)
+		}
+		if got := floatField(record, "rank"); !almostEqual(got, want, rankTolerance) {
+			return failed("vertex %d has rank %v, the reference %v", vertex, got, want)
+		}
+	}
+	return passed("ranks of all %d vertices match within %v", len(ranks), rankTolerance)
+}
+
+func (g *graph) hasArc(from, to int64, weightMatches func(float64) bool) bool {
+	for _, a := range g.arcs[from] {
+		if a.target == to && weightMatches(a.weight) {
+			return true
+		}
+	}
+	return false
+}
+
+// almostEqual compares floating point values written as text by the job. Without an absolute
+// tolerance the values may differ in their relative rounding only.
+func almostEqual(a, b, tolerance float64) bool {
+	if tolerance == 0 {
+		tolerance = 1e-9 * math.Max(1, math.Max(math.Abs(a), math.Abs(b)))
+	}
+	return math.Abs(a-b) <= tolerance
+}
+
+// Records and parameters were checked against their schemas, so their values always parse
+
+func intField(record map[string]string, name string) int64 {
+	value, _ := strconv.ParseInt(record[name], 10, 64)
+	return value
+}
+
+func floatField(record map[string]string, name string) float64 {
+	value, _ := strconv.ParseFloat(record[name], 64)
+	return value
+}
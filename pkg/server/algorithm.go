@@ -1,7 +1,9 @@
 package server
 
 import (
-    glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	"net/http"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
 )
 
 type AlgorithmService struct {
@@ -11,6 +13,34 @@ func NewAlgorithmService() *AlgorithmService {
 	return &AlgorithmService{}
 }
 
-func (a *AlgorithmService) Algorithm() []glconstants.Algorithm {
-	return []glconstants.Algorithm{glconstants.Kruskal, glconstants.Prim}
+// Algorithm lists the registered algorithms along with their parameters and supported graphs
+func (a *AlgorithmService) Algorithm() []glconstants.AlgorithmSpec {
+	return glconstants.Algorithms()
+}
+
+// validateAlgorithm checks the algorithm and its parameters against the registry. The algorithm
+// is replaced by its current name and the defaults of missing parameters are filled in.
+func validateAlgorithm(opts *ComputationOptions) error {
+	spec, ok := glconstants.LookupAlgorithm(opts.Algorithm)
+	if !ok {
+		return newStatusError(http.StatusBadRequest, "unknown algorithm %q", opts.Algorithm)
+	}
+
+	params, err := spec.ValidateParameters(opts.Parameters)
+	if err != nil {
+		return newStatusError(http.StatusBadRequest, "%v", err)
+	}
+
+	opts.Algorithm = spec.Name
+	opts.Parameters = params
+	return nil
+}
+
+// resultSchema returns the schema of the result of the algorithm, nil for unknown algorithms
+func resultSchema(algorithm glconstants.Algorithm) *glconstants.ResultSchema {
+	spec, ok := glconstants.LookupAlgorithm(algorithm)
+	if !ok {
+		return nil
+	}
+	return &spec.Result
 }
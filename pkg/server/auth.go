@@ -0,0 +1,194 @@
+package server
+
+import (
+	"bufio"
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"strings"
+
+	"github.com/go-kit/kit/endpoint"
+	httptransport "github.com/go-kit/kit/transport/http"
+)
+
+// Principal is an authenticated user of the API
+type Principal struct {
+	Name   string
+	Groups []string
+}
+
+// ErrNoCredentials is returned by an Authenticator when the request carries
+// no credentials of the kind it understands, or credentials it does not recognise,
+// so that the next Authenticator of a chain is tried.
+var ErrNoCredentials = errors.New("no credentials")
+
+// Authenticator extracts the Principal from an incoming HTTP request
+type Authenticator interface {
+	Authenticate(r *http.Request) (*Principal, error)
+}
+
+// ChainAuthenticator tries each Authenticator in order and returns the result of
+// the first one which found credentials it understands.
+type ChainAuthenticator []Authenticator
+
+func (c ChainAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	for _, authenticator := range c {
+		principal, err := authenticator.Authenticate(r)
+		if errors.Is(err, ErrNoCredentials) {
+			continue
+		}
+		return principal, err
+	}
+
+	return nil, ErrNoCredentials
+}
+
+func bearerToken(r *http.Request) (string, bool) {
+	header := r.Header.Get("Authorization")
+	if len(header) < len("Bearer ") || !strings.EqualFold(header[:len("Bearer ")], "Bearer ") {
+		return "", false
+	}
+
+	token := strings.TrimSpace(header[len("Bearer "):])
+	return token, token != ""
+}
+
+// StaticTokenAuthenticator authenticates requests carrying one of a fixed set of bearer tokens
+type StaticTokenAuthenticator struct {
+	tokens map[string]Principal
+}
+
+func NewStaticTokenAuthenticator(tokens map[string]Principal) *StaticTokenAuthenticator {
+	return &StaticTokenAuthenticator{tokens: tokens}
+}
+
+// LoadStaticTokenAuthenticator reads tokens from a file with one "token,user[,group...]" entry per line.
+// Empty lines and lines starting with '#' are ignored.
+func LoadStaticTokenAuthenticator(path string) (*StaticTokenAuthenticator, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	tokens := make(map[string]Principal)
+	scanner := bufio.NewScanner(file)
+	for lineNo := 1; scanner.Scan(); lineNo++ {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		fields := strings.Split(line, ",")
+		if len(fields) < 2 || fields[0] == "" || fields[1] == "" {
+			return nil, fmt.Errorf("%s:%d: expected token,user[,group...]", path, lineNo)
+		}
+
+		tokens[fields[0]] = Principal{Name: fields[1], Groups: fields[2:]}
+	}
+
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	return NewStaticTokenAuthenticator(tokens), nil
+}
+
+func (a *StaticTokenAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	token, ok := bearerToken(r)
+	if !ok {
+		return nil, ErrNoCredentials
+	}
+
+	// Other authenticators of the chain may know the token, e.g. as a JWT
+	principal, ok := a.tokens[token]
+	if !ok {
+		return nil, fmt.Errorf("unknown bearer token: %w", ErrNoCredentials)
+	}
+
+	return &principal, nil
+}
+
+// ClientCertAuthenticator authenticates requests by their verified TLS client certificate.
+// The certificate common name is used as the user name and its organizations as groups.
+type ClientCertAuthenticator struct{}
+
+func (ClientCertAuthenticator) Authenticate(r *http.Request) (*Principal, error) {
+	if r.TLS == nil || len(r.TLS.VerifiedChains) == 0 || len(r.TLS.VerifiedChains[0]) == 0 {
+		return nil, ErrNoCredentials
+	}
+
+	subject := r.TLS.VerifiedChains[0][0].Subject
+	if subject.CommonName == "" {
+		return nil, fmt.Errorf("client certificate has no common name: %w", ErrNoCredentials)
+	}
+
+	return &Principal{Name: subject.CommonName, Groups: subject.Organization}, nil
+}
+
+// NewClientCertTLSConfig returns a server TLS config verifying client certificates against
+// the CA bundle in caFile. Clients without a certificate are still accepted so that
+// other authenticators can be chained after ClientCertAuthenticator.
+func NewClientCertTLSConfig(caFile string) (*tls.Config, error) {
+	caPEM, err := os.ReadFile(caFile)
+	if err != nil {
+		return nil, err
+	}
+
+	pool := x509.NewCertPool()
+	if !pool.AppendCertsFromPEM(caPEM) {
+		return nil, fmt.Errorf("no certificates found in %s", caFile)
+	}
+
+	return &tls.Config{
+		ClientCAs:  pool,
+		ClientAuth: tls.VerifyClientCertIfGiven,
+		MinVersion: tls.VersionTLS12,
+	}, nil
+}
+
+type authContextKey int
+
+const (
+	principalContextKey authContextKey = iota
+	authErrorContextKey
+)
+
+// HTTPToContext authenticates the request and stores the outcome in the context.
+// Use it as a httptransport.ServerBefore option.
+func HTTPToContext(authenticator Authenticator) httptransport.RequestFunc {
+	return func(ctx context.Context, r *http.Request) context.Context {
+		principal, err := authenticator.Authenticate(r)
+		if err != nil {
+			return context.WithValue(ctx, authErrorContextKey, err)
+		}
+
+		return context.WithValue(ctx, principalContextKey, principal)
+	}
+}
+
+// PrincipalFromContext returns the Principal stored by HTTPToContext
+func PrincipalFromContext(ctx context.Context) (*Principal, bool) {
+	principal, ok := ctx.Value(principalContextKey).(*Principal)
+	return principal, ok
+}
+
+// NewAuthenticationMiddleware rejects requests that HTTPToContext could not authenticate
+func NewAuthenticationMiddleware() endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			if _, ok := PrincipalFromContext(ctx); !ok {
+				if err, ok := ctx.Value(authErrorContextKey).(error); ok && !errors.Is(err, ErrNoCredentials) {
+					return nil, newStatusError(ErrUnauthenticated.Code, "%s: %v", ErrUnauthenticated.Message, err)
+				}
+				return nil, ErrUnauthenticated
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
@@ -0,0 +1,91 @@
+package server
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+// fixedAuthenticator returns the same outcome for every request
+type fixedAuthenticator struct {
+	principal *Principal
+	err       error
+}
+
+func (a fixedAuthenticator) Authenticate(*http.Request) (*Principal, error) {
+	return a.principal, a.err
+}
+
+func TestStaticTokenAuthenticator(t *testing.T) {
+	authenticator := NewStaticTokenAuthenticator(map[string]Principal{"secret": {Name: "alice"}})
+
+	principal, err := authenticator.Authenticate(bearerRequest("secret"))
+	if err != nil || principal.Name != "alice" {
+		t.Errorf("got %+v, %v", principal, err)
+	}
+
+	if _, err := authenticator.Authenticate(bearerRequest("other")); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("unknown token: got %v, want no credentials", err)
+	}
+}
+
+func TestClientCertAuthenticator(t *testing.T) {
+	request := func(subject pkix.Name) *http.Request {
+		r, _ := http.NewRequest(http.MethodGet, "/computations", nil)
+		r.TLS = &tls.ConnectionState{VerifiedChains: [][]*x509.Certificate{{{Subject: subject}}}}
+		return r
+	}
+
+	principal, err := ClientCertAuthenticator{}.Authenticate(request(pkix.Name{CommonName: "alice", Organization: []string{"admins"}}))
+	if err != nil || principal.Name != "alice" || len(principal.Groups) != 1 {
+		t.Errorf("got %+v, %v", principal, err)
+	}
+
+	if _, err := (ClientCertAuthenticator{}).Authenticate(request(pkix.Name{})); !errors.Is(err, ErrNoCredentials) {
+		t.Errorf("certificate without common name: got %v, want no credentials", err)
+	}
+}
+
+func TestChainAuthenticator(t *testing.T) {
+	alice := &Principal{Name: "alice"}
+	invalid := errors.New("invalid JWT")
+
+	tests := map[string]struct {
+		chain ChainAuthenticator
+		want  *Principal
+		err   error
+	}{
+		"first authenticates":   {ChainAuthenticator{fixedAuthenticator{alice, nil}, fixedAuthenticator{nil, invalid}}, alice, nil},
+		"falls through":         {ChainAuthenticator{fixedAuthenticator{nil, ErrNoCredentials}, fixedAuthenticator{alice, nil}}, alice, nil},
+		"static token unknown":  {ChainAuthenticator{NewStaticTokenAuthenticator(nil), fixedAuthenticator{alice, nil}}, alice, nil},
+		"stops at invalid":      {ChainAuthenticator{fixedAuthenticator{nil, invalid}, fixedAuthenticator{alice, nil}}, nil, invalid},
+		"nothing authenticates": {ChainAuthenticator{fixedAuthenticator{nil, ErrNoCredentials}}, nil, ErrNoCredentials},
+		"no authenticators":     {ChainAuthenticator{}, nil, ErrNoCredentials},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			principal, err := tt.chain.Authenticate(bearerRequest("token"))
+			if principal != tt.want || !errors.Is(err, tt.err) || (err == nil) != (tt.err == nil) {
+				t.Errorf("got %+v, %v, want %+v, %v", principal, err, tt.want, tt.err)
+			}
+		})
+	}
+}
+
+func TestChainWithJWT(t *testing.T) {
+	chain := ChainAuthenticator{NewStaticTokenAuthenticator(map[string]Principal{"secret": {Name: "bob"}}), newTestJWTAuthenticator(t)}
+
+	principal, err := chain.Authenticate(bearerRequest(signJWT(t, "RS256", "rsa", testRSAKey, validClaims())))
+	if err != nil || principal.Name != "alice" {
+		t.Errorf("JWT: got %+v, %v", principal, err)
+	}
+
+	principal, err = chain.Authenticate(bearerRequest("secret"))
+	if err != nil || principal.Name != "bob" {
+		t.Errorf("static token: got %+v, %v", principal, err)
+	}
+}
@@ -1,38 +1,124 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"io"
+	"sort"
 
 	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	glgraph "github.com/lnikon/glfs-pkg/pkg/graph"
 	glkube "github.com/lnikon/glfs-pkg/pkg/kube"
+	upcxxv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
 )
 
 const (
 	ComputationDeploymentNamePattern = "computation-%d"
+
+	// Highest admission priority a client may request. Higher priorities are kept for
+	// jobs created directly in the cluster, which the HTTP API cannot overtake.
+	MaxComputationPriority = 100
 )
 
 type Computation struct {
 	Algorithm glconstants.Algorithm `json:"algorithm"`
 	Name      string                `json:"name"`
+	Owner     string                `json:"owner,omitempty"`
+	Phase     string                `json:"phase,omitempty"`
+
+	Priority      int32 `json:"priority"`
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Schema of the records the algorithm writes as its result
+	Result *glconstants.ResultSchema `json:"result,omitempty"`
+
+	// Verdict of the result verification, when requested
+	Verification *upcxxv1alpha1.VerificationStatus `json:"verification,omitempty"`
 }
 
 func (c *Computation) String() string {
-	return fmt.Sprintf("{Algorithm: %v, Name: %v}", c.Algorithm, c.Name)
+	return fmt.Sprintf("{Algorithm: %v, Name: %v, Owner: %v}", c.Algorithm, c.Name, c.Owner)
+}
+
+func computationFromUPCXX(upcxx *upcxxv1alpha1.UPCXX) Computation {
+	return Computation{
+		Name:      upcxx.Name,
+		Algorithm: upcxx.Spec.Algorithm,
+		Owner:     upcxx.Annotations[upcxxv1alpha1.OwnerAnnotation],
+		Phase:     string(upcxx.Status.Phase),
+
+		Priority:      upcxx.Spec.Priority,
+		QueuePosition: upcxx.Status.QueuePosition,
+
+		Parameters: upcxx.Spec.Parameters,
+		Result:     resultSchema(upcxx.Spec.Algorithm),
+
+		Verification: upcxx.Status.Verification,
+	}
+}
+
+// ComputationOptions describes a computation to create
+type ComputationOptions struct {
+	Algorithm   glconstants.Algorithm
+	Owner       string
+	WorkerCount int32
+	Priority    int32
+
+	// Parameters of the algorithm, validated against its parameter schema
+	Parameters map[string]string
+
+	// Keep worker volumes and result artifacts after the computation is deleted
+	RetainData bool
+
+	// Verify the result against the reference implementation of the algorithm. The input is
+	// verified when given, the input path of the spec is then left empty.
+	Verify *upcxxv1alpha1.VerifySpec
+
+	// Graph the algorithm runs on, validated and normalized before the computation is created
+	Input *InputGraph
 }
 
 type ComputationServiceIfc interface {
 	GetComputation(name string) (*Computation, error)
 	GetAllComputations() []Computation
-	PostComputation(algorithm glconstants.Algorithm) (*Computation, error)
+	GetQueue() []Computation
+	PostComputation(opts ComputationOptions) (*Computation, error)
+	RenderComputation(opts ComputationOptions) ([]byte, error)
 	DeleteComputation(name string) error
+	GetComputationLogs(ctx context.Context, name string, opts glkube.LogOptions) (io.ReadCloser, error)
 }
 
 type ComputationService struct {
 	computations []Computation
+
+	userQuota   Quota
+	globalQuota Quota
+}
+
+// ComputationServiceOption configures optional behaviour of the ComputationService
+type ComputationServiceOption func(*ComputationService)
+
+// WithUserQuota limits the computations running and waiting at the same time for a single user
+func WithUserQuota(quota Quota) ComputationServiceOption {
+	return func(c *ComputationService) {
+		c.userQuota = quota
+	}
+}
+
+// WithGlobalQuota limits the computations running and waiting at the same time for all users
+func WithGlobalQuota(quota Quota) ComputationServiceOption {
+	return func(c *ComputationService) {
+		c.globalQuota = quota
+	}
 }
 
-func NewComputationService() (ComputationServiceIfc, error) {
+func NewComputationService(options ...ComputationServiceOption) (ComputationServiceIfc, error) {
 	computationService := &ComputationService{}
+	for _, option := range options {
+		option(computationService)
+	}
 
 	// deploymentsList := glkube.GetAllDeployments()
 	// if deploymentsList == nil {
@@ -62,31 +148,91 @@ func (c *ComputationService) generateComputationName() string {
 func (c *ComputationService) GetAllComputations() []Computation {
 	upcxxList := glkube.GetAllDeployments()
 	var computations []Computation
-	for _, upcxx := range upcxxList.Items {
-		computations = append(computations, Computation{
-			Name:      upcxx.Spec.StatefulSetName,
-			Algorithm: "Prim",
-		})
+	for i := range upcxxList.Items {
+		computations = append(computations, computationFromUPCXX(&upcxxList.Items[i]))
 	}
 
 	return computations
 }
 
+// GetQueue returns the computations waiting in the admission queue, in admission order
+func (c *ComputationService) GetQueue() []Computation {
+	var queue []Computation
+	for _, computation := range c.GetAllComputations() {
+		if computation.QueuePosition > 0 {
+			queue = append(queue, computation)
+		}
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		return queue[i].QueuePosition < queue[j].QueuePosition
+	})
+
+	return queue
+}
+
 func (c *ComputationService) GetComputation(name string) (*Computation, error) {
 	upcxx := glkube.GetDeployment(name)
 	if upcxx == nil {
 		return nil, fmt.Errorf("resource does not exists")
 	}
 
-	return &Computation{
-		Name:      upcxx.Spec.StatefulSetName,
-		Algorithm: upcxx.Spec.Algorithm,
+	computation := computationFromUPCXX(upcxx)
+	return &computation, nil
+}
+
+// buildUPCXXOptions defaults the worker count, validates the algorithm and its parameters and
+// normalizes the input of the computation into the options of its UPCXX
+func buildUPCXXOptions(opts ComputationOptions) (glkube.UPCXXOptions, error) {
+	if opts.WorkerCount == 0 {
+		opts.WorkerCount = glkube.DefaultWorkerCount
+	}
+
+	if err := validateAlgorithm(&opts); err != nil {
+		return glkube.UPCXXOptions{}, err
+	}
+
+	var input []byte
+	var directed bool
+	if opts.Input != nil {
+		var err error
+		if input, directed, err = normalizeInput(opts.Algorithm, opts.Input); err != nil {
+			return glkube.UPCXXOptions{}, err
+		}
+	}
+
+	return glkube.UPCXXOptions{
+		Algorithm:     opts.Algorithm,
+		Parameters:    opts.Parameters,
+		Owner:         opts.Owner,
+		WorkerCount:   opts.WorkerCount,
+		Priority:      opts.Priority,
+		RetainData:    opts.RetainData,
+		Verify:        opts.Verify,
+		Input:         input,
+		InputFormat:   string(glgraph.EdgeList),
+		InputDirected: directed,
 	}, nil
 }
 
-func (c *ComputationService) PostComputation(algorithm glconstants.Algorithm) (*Computation, error) {
-	computation := Computation{Algorithm: algorithm, Name: c.generateComputationName()}
-	if err := glkube.CreateUPCXX(computation.Name); err != nil {
+func (c *ComputationService) PostComputation(opts ComputationOptions) (*Computation, error) {
+	upcxxOptions, err := buildUPCXXOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := c.checkQuotas(upcxxOptions.Owner, upcxxOptions.WorkerCount); err != nil {
+		return nil, err
+	}
+
+	computation := Computation{
+		Algorithm:  upcxxOptions.Algorithm,
+		Name:       c.generateComputationName(),
+		Owner:      upcxxOptions.Owner,
+		Parameters: upcxxOptions.Parameters,
+		Result:     resultSchema(upcxxOptions.Algorithm),
+	}
+	if err := glkube.CreateUPCXX(computation.Name, upcxxOptions); err != nil {
 		return &computation, err
 	}
 
@@ -94,7 +240,29 @@ func (c *ComputationService) PostComputation(algorithm glconstants.Algorithm) (*
 	return &computation, nil
 }
 
+// RenderComputation renders the Kubernetes objects PostComputation would create for the
+// computation as YAML, without touching the cluster. Quotas are not checked.
+func (c *ComputationService) RenderComputation(opts ComputationOptions) ([]byte, error) {
+	upcxxOptions, err := buildUPCXXOptions(opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return glkube.RenderUPCXX(c.generateComputationName(), upcxxOptions)
+}
+
 func (c *ComputationService) DeleteComputation(name string) error {
-	//return glkube.CreateUPCXX(name)
-	return nil
+	if upcxx := glkube.GetDeployment(name); upcxx == nil {
+		return fmt.Errorf("resource does not exists")
+	}
+
+	return glkube.DeleteDeployment(name)
+}
+
+func (c *ComputationService) GetComputationLogs(ctx context.Context, name string, opts glkube.LogOptions) (io.ReadCloser, error) {
+	if upcxx := glkube.GetDeployment(name); upcxx == nil {
+		return nil, fmt.Errorf("resource does not exists")
+	}
+
+	return glkube.GetComputationLogs(ctx, name, opts)
 }
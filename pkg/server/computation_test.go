@@ -0,0 +1,37 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	glkube "github.com/lnikon/glfs-pkg/pkg/kube"
+)
+
+func TestBuildUPCXXOptions(t *testing.T) {
+	opts, err := buildUPCXXOptions(ComputationOptions{
+		Algorithm: "mst",
+		Owner:     "alice",
+		Input:     &InputGraph{Data: "0 1 2\n1 2 3\n"},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if opts.Algorithm != glconstants.Prim {
+		t.Errorf("algorithm = %s, want %s", opts.Algorithm, glconstants.Prim)
+	}
+	if opts.WorkerCount != glkube.DefaultWorkerCount {
+		t.Errorf("workerCount = %d, want the default %d", opts.WorkerCount, glkube.DefaultWorkerCount)
+	}
+	if want := "0 1 2\n1 2 3\n"; string(opts.Input) != want {
+		t.Errorf("input = %q, want %q", opts.Input, want)
+	}
+	if opts.Owner != "alice" {
+		t.Errorf("owner = %q, want alice", opts.Owner)
+	}
+
+	if _, err := buildUPCXXOptions(ComputationOptions{Algorithm: "unknown"}); statusCode(err) != http.StatusBadRequest {
+		t.Errorf("unknown algorithm: error = %v, want status %d", err, http.StatusBadRequest)
+	}
+}
@@ -0,0 +1,30 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+)
+
+// StatusError is an error reported to the client with a specific HTTP status code.
+// It implements the go-kit httptransport.StatusCoder interface.
+type StatusError struct {
+	Code    int
+	Message string
+}
+
+func (e *StatusError) Error() string {
+	return e.Message
+}
+
+func (e *StatusError) StatusCode() int {
+	return e.Code
+}
+
+func newStatusError(code int, format string, args ...interface{}) *StatusError {
+	return &StatusError{Code: code, Message: fmt.Sprintf(format, args...)}
+}
+
+var (
+	ErrUnauthenticated = newStatusError(http.StatusUnauthorized, "unauthenticated")
+	ErrForbidden       = newStatusError(http.StatusForbidden, "forbidden")
+)
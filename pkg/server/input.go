@@ -0,0 +1,125 @@
+package server
+
+import (
+	"bytes"
+	"errors"
+	"net/http"
+	"strings"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	glgraph "github.com/lnikon/glfs-pkg/pkg/graph"
+)
+
+// Largest normalized input stored along with a computation, ConfigMaps hold at most 1MiB
+const maxInputSize = 1000 * 1000
+
+// Largest request body accepted when a computation is created. Uploaded files may be larger
+// than their normalization, and JSON escapes their line breaks.
+const maxRequestBodySize = 8 * maxInputSize
+
+var errInputTooLarge = errors.New("normalized input too large")
+
+// limitedBuffer is a bytes.Buffer failing writes which would grow it past limit bytes, so
+// that a large input is rejected as soon as its normalization reaches the limit
+type limitedBuffer struct {
+	bytes.Buffer
+	limit int
+}
+
+func (b *limitedBuffer) Write(p []byte) (int, error) {
+	if b.Len()+len(p) > b.limit {
+		return 0, errInputTooLarge
+	}
+	return b.Buffer.Write(p)
+}
+
+// InputGraph is a graph uploaded along with a computation. It is renumbered to vertices from 0,
+// so vertices in the parameters and the result of the computation are numbered from 0 even when
+// the file numbers them from 1, like Matrix Market, METIS and DIMACS files do.
+type InputGraph struct {
+	// Contents of the graph file
+	Data string `json:"data"`
+
+	// Format of the file, one of edgelist, csv, tsv, mtx, metis or dimacs. Detected from the
+	// contents when unset.
+	Format string `json:"format,omitempty"`
+
+	// Whether the edges of an edge list are directed. Matrix Market, METIS and DIMACS files
+	// declare it themselves.
+	Directed bool `json:"directed,omitempty"`
+}
+
+// normalizeInput validates the uploaded graph against the graph types the algorithm runs on and
+// converts it to a whitespace separated edge list with vertices numbered from 0, the format
+// the algorithms read. It reports whether the graph is directed, which the edge list no longer
+// declares.
+func normalizeInput(algorithm glconstants.Algorithm, input *InputGraph) ([]byte, bool, error) {
+	var (
+		reader glgraph.Reader
+		format glgraph.Format
+		err    error
+	)
+	if input.Format == "" {
+		reader, format, err = glgraph.NewDetectingReader(strings.NewReader(input.Data))
+	} else {
+		if format, err = glgraph.ParseFormat(input.Format); err != nil {
+			return nil, false, newStatusError(http.StatusBadRequest, "%v", err)
+		}
+		reader, err = glgraph.NewReader(strings.NewReader(input.Data), format)
+	}
+	if err != nil {
+		return nil, false, newStatusError(http.StatusBadRequest, "invalid input: %v", err)
+	}
+
+	header := reader.Header()
+	switch format {
+	case glgraph.EdgeList, glgraph.CSV, glgraph.TSV:
+		header.Directed = input.Directed
+	}
+	if err := checkGraphType(algorithm, header); err != nil {
+		return nil, false, err
+	}
+
+	normalized := &limitedBuffer{limit: maxInputSize}
+	writer, err := glgraph.NewWriter(normalized, glgraph.EdgeList, header)
+	if err != nil {
+		return nil, false, err
+	}
+	edges, err := glgraph.Copy(writer, reader)
+	if errors.Is(err, errInputTooLarge) {
+		return nil, false, newStatusError(http.StatusRequestEntityTooLarge,
+			"normalized input has more than %d bytes", maxInputSize)
+	}
+	if err != nil {
+		return nil, false, newStatusError(http.StatusBadRequest, "invalid %s input: %v", format, err)
+	}
+	if edges == 0 {
+		return nil, false, newStatusError(http.StatusBadRequest, "input graph has no edges")
+	}
+
+	return normalized.Bytes(), header.Directed, nil
+}
+
+// checkGraphType checks that the algorithm runs on graphs like the input. Unweighted inputs
+// are accepted by weighted algorithms, every edge then weighs 1.
+func checkGraphType(algorithm glconstants.Algorithm, header glgraph.Header) error {
+	spec, ok := glconstants.LookupAlgorithm(algorithm)
+	if !ok {
+		return newStatusError(http.StatusBadRequest, "unknown algorithm %q", algorithm)
+	}
+
+	for _, graphType := range spec.GraphTypes {
+		if graphType.Directed == header.Directed && (graphType.Weighted || !header.Weighted) {
+			return nil
+		}
+	}
+
+	kind := "undirected"
+	if header.Directed {
+		kind = "directed"
+	}
+	if header.Weighted {
+		kind += " weighted"
+	}
+	return newStatusError(http.StatusBadRequest, "%s does not run on %s graphs", spec.Name, kind)
+}
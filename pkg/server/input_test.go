@@ -0,0 +1,132 @@
+package server
+
+import (
+	"errors"
+	"net/http"
+	"strings"
+	"testing"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	glgraph "github.com/lnikon/glfs-pkg/pkg/graph"
+)
+
+func statusCode(err error) int {
+	var statusErr *StatusError
+	if errors.As(err, &statusErr) {
+		return statusErr.Code
+	}
+	return 0
+}
+
+func TestNormalizeInput(t *testing.T) {
+	tests := map[string]struct {
+		algorithm glconstants.Algorithm
+		input     InputGraph
+		want      string
+		directed  bool
+	}{
+		"edge list": {
+			algorithm: glconstants.Kruskal,
+			input:     InputGraph{Data: "0 1 2\n1 2 3\n", Format: "edgelist"},
+			want:      "0 1 2\n1 2 3\n",
+		},
+		"unweighted input of a weighted algorithm": {
+			algorithm: glconstants.Kruskal,
+			input:     InputGraph{Data: "0 1\n1 2\n"},
+			want:      "0 1\n1 2\n",
+		},
+		"directed csv": {
+			algorithm: glconstants.BFS,
+			input:     InputGraph{Data: "source,target\n0,1\n1,2\n", Format: "csv", Directed: true},
+			want:      "0 1\n1 2\n",
+			directed:  true,
+		},
+		"detected matrix market renumbered from 1": {
+			algorithm: glconstants.Prim,
+			input:     InputGraph{Data: "%%MatrixMarket matrix coordinate real symmetric\n3 3 2\n2 1 1.5\n3 2 2\n"},
+			want:      "0 1 1.5\n1 2 2\n",
+		},
+		"directed dimacs": {
+			algorithm: glconstants.SSSP,
+			input:     InputGraph{Data: "p sp 2 1\na 1 2 3\n", Format: "dimacs"},
+			want:      "0 1 3\n",
+			directed:  true,
+		},
+		"legacy algorithm name": {
+			algorithm: "mst",
+			input:     InputGraph{Data: "0 1 1\n"},
+			want:      "0 1 1\n",
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			got, directed, err := normalizeInput(tt.algorithm, &tt.input)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if string(got) != tt.want {
+				t.Errorf("got %q, want %q", got, tt.want)
+			}
+			if directed != tt.directed {
+				t.Errorf("directed = %v, want %v", directed, tt.directed)
+			}
+		})
+	}
+}
+
+func TestNormalizeInputErrors(t *testing.T) {
+	tests := map[string]struct {
+		algorithm glconstants.Algorithm
+		input     InputGraph
+		code      int
+	}{
+		"unknown format":            {glconstants.BFS, InputGraph{Data: "0 1\n", Format: "gml"}, http.StatusBadRequest},
+		"invalid edge":              {glconstants.BFS, InputGraph{Data: "0 1\n-1 2\n"}, http.StatusBadRequest},
+		"no edges":                  {glconstants.BFS, InputGraph{Data: "# only a comment\n"}, http.StatusBadRequest},
+		"directed input of kruskal": {glconstants.Kruskal, InputGraph{Data: "0 1 1\n", Directed: true}, http.StatusBadRequest},
+		"unknown algorithm":         {"dfs", InputGraph{Data: "0 1\n"}, http.StatusBadRequest},
+		"too large": {
+			glconstants.BFS,
+			InputGraph{Data: strings.Repeat("1000000 1000001\n", maxInputSize/16+1)},
+			http.StatusRequestEntityTooLarge,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := normalizeInput(tt.algorithm, &tt.input)
+			if code := statusCode(err); code != tt.code {
+				t.Errorf("got %v with status %d, want status %d", err, code, tt.code)
+			}
+		})
+	}
+}
+
+func TestCheckGraphType(t *testing.T) {
+	tests := []struct {
+		algorithm glconstants.Algorithm
+		header    glgraph.Header
+		accepted  bool
+	}{
+		{glconstants.Kruskal, glgraph.Header{Weighted: true}, true},
+		{glconstants.Kruskal, glgraph.Header{}, true},
+		{glconstants.Kruskal, glgraph.Header{Directed: true, Weighted: true}, false},
+		{glconstants.Prim, glgraph.Header{Directed: true}, false},
+		{glconstants.SSSP, glgraph.Header{Directed: true, Weighted: true}, true},
+		{glconstants.SSSP, glgraph.Header{Directed: true}, true},
+		{glconstants.ConnectedComponents, glgraph.Header{Weighted: true}, true},
+		{glconstants.ConnectedComponents, glgraph.Header{Directed: true}, false},
+		{glconstants.PageRank, glgraph.Header{Directed: true, Weighted: true}, true},
+	}
+
+	for _, tt := range tests {
+		err := checkGraphType(tt.algorithm, tt.header)
+		if tt.accepted && err != nil {
+			t.Errorf("%s rejected %+v: %v", tt.algorithm, tt.header, err)
+		}
+		if !tt.accepted && statusCode(err) != http.StatusBadRequest {
+			t.Errorf("%s on %+v: got %v, want a bad request", tt.algorithm, tt.header, err)
+		}
+	}
+}
@@ -0,0 +1,248 @@
+package server
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+var (
+	testRSAKey, _  = rsa.GenerateKey(rand.Reader, 2048)
+	testP256Key, _ = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	testP521Key, _ = ecdsa.GenerateKey(elliptic.P521(), rand.Reader)
+)
+
+func encodeBase64URL(data []byte) string {
+	return base64.RawURLEncoding.EncodeToString(data)
+}
+
+// ecCoordinate encodes a coordinate of an EC point padded to the size of the curve
+func ecCoordinate(curve elliptic.Curve, i *big.Int) string {
+	return encodeBase64URL(i.FillBytes(make([]byte, (curve.Params().BitSize+7)/8)))
+}
+
+func writeJWKS(t *testing.T, keys ...map[string]string) string {
+	t.Helper()
+	data, err := json.Marshal(map[string]interface{}{"keys": keys})
+	if err != nil {
+		t.Fatal(err)
+	}
+	path := filepath.Join(t.TempDir(), "jwks.json")
+	if err := os.WriteFile(path, data, 0600); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func rsaJWK(kid string, key *rsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "RSA",
+		"kid": kid,
+		"n":   encodeBase64URL(key.N.Bytes()),
+		"e":   encodeBase64URL(big.NewInt(int64(key.E)).Bytes()),
+	}
+}
+
+func ecJWK(kid, crv string, key *ecdsa.PublicKey) map[string]string {
+	return map[string]string{
+		"kty": "EC",
+		"kid": kid,
+		"crv": crv,
+		"x":   ecCoordinate(key.Curve, key.X),
+		"y":   ecCoordinate(key.Curve, key.Y),
+	}
+}
+
+func newTestJWTAuthenticator(t *testing.T) *JWTAuthenticator {
+	t.Helper()
+	authenticator, err := NewJWTAuthenticator(writeJWKS(t,
+		rsaJWK("rsa", &testRSAKey.PublicKey),
+		ecJWK("p256", "P-256", &testP256Key.PublicKey),
+		ecJWK("p521", "P-521", &testP521Key.PublicKey),
+	), "https://issuer.example.com", "glfs")
+	if err != nil {
+		t.Fatal(err)
+	}
+	return authenticator
+}
+
+// signJWT signs the claims with the key as alg, without checking that they match
+func signJWT(t *testing.T, alg, kid string, key crypto.Signer, claims map[string]interface{}) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := encodeBase64URL(header) + "." + encodeBase64URL(payload)
+
+	hash := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}[alg[2:]]
+	hasher := hash.New()
+	hasher.Write([]byte(signed))
+	digest := hasher.Sum(nil)
+
+	var signature []byte
+	switch key := key.(type) {
+	case *rsa.PrivateKey:
+		var err error
+		if signature, err = rsa.SignPKCS1v15(rand.Reader, key, hash, digest); err != nil {
+			t.Fatal(err)
+		}
+	case *ecdsa.PrivateKey:
+		r, s, err := ecdsa.Sign(rand.Reader, key, digest)
+		if err != nil {
+			t.Fatal(err)
+		}
+		size := (key.Curve.Params().BitSize + 7) / 8
+		signature = append(r.FillBytes(make([]byte, size)), s.FillBytes(make([]byte, size))...)
+	}
+	return signed + "." + encodeBase64URL(signature)
+}
+
+func validClaims() map[string]interface{} {
+	return map[string]interface{}{
+		"sub":    "alice",
+		"groups": []string{"admins"},
+		"iss":    "https://issuer.example.com",
+		"aud":    "glfs",
+		"exp":    time.Now().Add(time.Hour).Unix(),
+	}
+}
+
+func bearerRequest(token string) *http.Request {
+	r, _ := http.NewRequest(http.MethodGet, "/computations", nil)
+	r.Header.Set("Authorization", "Bearer "+token)
+	return r
+}
+
+func TestJWTSignatures(t *testing.T) {
+	authenticator := newTestJWTAuthenticator(t)
+
+	tests := map[string]struct {
+		alg, kid string
+		key      crypto.Signer
+		valid    bool
+	}{
+		"RS256":                {"RS256", "rsa", testRSAKey, true},
+		"RS512":                {"RS512", "rsa", testRSAKey, true},
+		"ES256 on P-256":       {"ES256", "p256", testP256Key, true},
+		"ES512 on P-521":       {"ES512", "p521", testP521Key, true},
+		"ES256 on P-521":       {"ES256", "p521", testP521Key, false},
+		"ES512 on P-256":       {"ES512", "p256", testP256Key, false},
+		"RS256 with an EC key": {"RS256", "p256", testRSAKey, false},
+		"ES256 with RSA key":   {"ES256", "rsa", testP256Key, false},
+		"signed by other key":  {"ES256", "p256", mustECKey(t), false},
+		"none":                 {"no256", "rsa", testRSAKey, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			principal, err := authenticator.Authenticate(bearerRequest(signJWT(t, tt.alg, tt.kid, tt.key, validClaims())))
+			if tt.valid {
+				if err != nil {
+					t.Fatal(err)
+				}
+				if principal.Name != "alice" || len(principal.Groups) != 1 || principal.Groups[0] != "admins" {
+					t.Errorf("got principal %+v", principal)
+				}
+			} else if err == nil || errors.Is(err, ErrNoCredentials) {
+				t.Errorf("expected an invalid token, got %+v, %v", principal, err)
+			}
+		})
+	}
+}
+
+func mustECKey(t *testing.T) *ecdsa.PrivateKey {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return key
+}
+
+func TestJWTClaims(t *testing.T) {
+	authenticator := newTestJWTAuthenticator(t)
+	now := time.Now()
+
+	tests := map[string]struct {
+		change func(claims map[string]interface{})
+		valid  bool
+	}{
+		"valid":               {func(map[string]interface{}) {}, true},
+		"audience in a list":  {func(c map[string]interface{}) { c["aud"] = []string{"other", "glfs"} }, true},
+		"not valid before":    {func(c map[string]interface{}) { c["nbf"] = now.Add(-time.Minute).Unix() }, true},
+		"expired":             {func(c map[string]interface{}) { c["exp"] = now.Add(-time.Minute).Unix() }, false},
+		"without expiry":      {func(c map[string]interface{}) { delete(c, "exp") }, false},
+		"not valid yet":       {func(c map[string]interface{}) { c["nbf"] = now.Add(time.Hour).Unix() }, false},
+		"other issuer":        {func(c map[string]interface{}) { c["iss"] = "https://other.example.com" }, false},
+		"other audience":      {func(c map[string]interface{}) { c["aud"] = []string{"other"} }, false},
+		"without audience":    {func(c map[string]interface{}) { delete(c, "aud") }, false},
+		"without a user name": {func(c map[string]interface{}) { delete(c, "sub") }, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			claims := validClaims()
+			tt.change(claims)
+			_, err := authenticator.Authenticate(bearerRequest(signJWT(t, "ES256", "p256", testP256Key, claims)))
+			if tt.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
+
+func TestJWTUnknownTokens(t *testing.T) {
+	authenticator := newTestJWTAuthenticator(t)
+
+	for name, token := range map[string]string{
+		"not a JWT":      "static-token",
+		"unknown key id": signJWT(t, "ES256", "other", testP256Key, validClaims()),
+	} {
+		if _, err := authenticator.Authenticate(bearerRequest(token)); !errors.Is(err, ErrNoCredentials) {
+			t.Errorf("%s: got %v, want no credentials", name, err)
+		}
+	}
+}
+
+func TestJWKS(t *testing.T) {
+	sigOnly := rsaJWK("enc", &testRSAKey.PublicKey)
+	sigOnly["use"] = "enc"
+	offCurve := ecJWK("off", "P-256", &testP256Key.PublicKey)
+	offCurve["y"] = offCurve["x"]
+
+	tests := map[string]struct {
+		keys  []map[string]string
+		valid bool
+	}{
+		"RSA and EC keys":       {[]map[string]string{rsaJWK("rsa", &testRSAKey.PublicKey), ecJWK("p256", "P-256", &testP256Key.PublicKey)}, true},
+		"encryption keys only":  {[]map[string]string{sigOnly}, false},
+		"unsupported curve":     {[]map[string]string{ecJWK("p224", "P-224", &testP256Key.PublicKey)}, false},
+		"point off the curve":   {[]map[string]string{offCurve}, false},
+		"unsupported key type":  {[]map[string]string{{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"}}, false},
+		"invalid base64 in key": {[]map[string]string{{"kty": "RSA", "kid": "bad", "n": "!!", "e": "AQAB"}}, false},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := NewJWTAuthenticator(writeJWKS(t, tt.keys...), "", "")
+			if tt.valid && err != nil {
+				t.Fatal(err)
+			}
+			if !tt.valid && err == nil {
+				t.Error("expected an error")
+			}
+		})
+	}
+}
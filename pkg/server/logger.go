@@ -1,11 +1,13 @@
 package server
 
 import (
+	"context"
 	"fmt"
+	"io"
 	"time"
 
 	log "github.com/go-kit/log"
-	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	glkube "github.com/lnikon/glfs-pkg/pkg/kube"
 )
 
 type LoggingMiddleware struct {
@@ -44,17 +46,46 @@ func (mw LoggingMiddleware) GetAllComputations() (output []Computation) {
 	return
 }
 
-func (mw LoggingMiddleware) PostComputation(algorithm glconstants.Algorithm) (output *Computation, err error) {
+func (mw LoggingMiddleware) GetQueue() (output []Computation) {
+	defer func(begin time.Time) {
+		mw.Logger.Log(
+			"method", "GetQueue",
+			"output", fmt.Sprintf("%v", output),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output = mw.Next.GetQueue()
+	return
+}
+
+func (mw LoggingMiddleware) PostComputation(opts ComputationOptions) (output *Computation, err error) {
 	defer func(begin time.Time) {
 		mw.Logger.Log(
 			"method", "PostComputation",
-			"input", fmt.Sprintf("%v", algorithm),
+			"input", fmt.Sprintf("%+v", opts),
 			"output", fmt.Sprintf("%v", output),
 			"took", time.Since(begin),
 		)
 	}(time.Now())
 
-	output, err = mw.Next.PostComputation(algorithm)
+	output, err = mw.Next.PostComputation(opts)
+	if err != nil {
+		mw.Logger.Log("Error: ", err.Error())
+	}
+	return
+}
+
+func (mw LoggingMiddleware) RenderComputation(opts ComputationOptions) (output []byte, err error) {
+	defer func(begin time.Time) {
+		mw.Logger.Log(
+			"method", "RenderComputation",
+			"input", fmt.Sprintf("%+v", opts),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.Next.RenderComputation(opts)
 	if err != nil {
 		mw.Logger.Log("Error: ", err.Error())
 	}
@@ -76,3 +107,19 @@ func (mw LoggingMiddleware) DeleteComputation(name string) (err error) {
 	}
 	return
 }
+
+func (mw LoggingMiddleware) GetComputationLogs(ctx context.Context, name string, opts glkube.LogOptions) (output io.ReadCloser, err error) {
+	defer func(begin time.Time) {
+		mw.Logger.Log(
+			"method", "GetComputationLogs",
+			"input", fmt.Sprintf("%v %+v", name, opts),
+			"took", time.Since(begin),
+		)
+	}(time.Now())
+
+	output, err = mw.Next.GetComputationLogs(ctx, name, opts)
+	if err != nil {
+		mw.Logger.Log("Error: ", err.Error())
+	}
+	return
+}
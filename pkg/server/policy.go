@@ -0,0 +1,191 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"os"
+
+	"github.com/go-kit/kit/endpoint"
+)
+
+// Action is an operation a Principal performs on a computation
+type Action string
+
+const (
+	ActionCreate Action = "create"
+	ActionView   Action = "view"
+	ActionDelete Action = "delete"
+)
+
+// Policy decides whether a Principal may perform an action on a computation.
+// owner is the owner of the computation and is empty for ActionCreate.
+type Policy interface {
+	Allowed(principal *Principal, action Action, owner string) bool
+}
+
+// Rule grants actions to users and groups. "*" matches any user or action.
+type Rule struct {
+	Users   []string `json:"users"`
+	Groups  []string `json:"groups"`
+	Actions []Action `json:"actions"`
+
+	// AnyOwner extends the rule to computations owned by other users
+	AnyOwner bool `json:"anyOwner"`
+}
+
+func (r *Rule) matches(principal *Principal, action Action, owner string) bool {
+	if !r.AnyOwner && action != ActionCreate && owner != principal.Name {
+		return false
+	}
+
+	actionMatches := false
+	for _, a := range r.Actions {
+		if a == action || a == "*" {
+			actionMatches = true
+			break
+		}
+	}
+	if !actionMatches {
+		return false
+	}
+
+	for _, user := range r.Users {
+		if user == principal.Name || user == "*" {
+			return true
+		}
+	}
+
+	for _, group := range r.Groups {
+		for _, principalGroup := range principal.Groups {
+			if group == principalGroup {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
+// RulePolicy allows an action when any of its rules allows it
+type RulePolicy struct {
+	Rules []Rule `json:"rules"`
+}
+
+// NewDefaultPolicy lets every authenticated user create computations
+// and view or delete the ones they own.
+func NewDefaultPolicy() *RulePolicy {
+	return &RulePolicy{
+		Rules: []Rule{
+			{Users: []string{"*"}, Actions: []Action{ActionCreate, ActionView, ActionDelete}},
+		},
+	}
+}
+
+// LoadRulePolicy reads a RulePolicy from a JSON file
+func LoadRulePolicy(path string) (*RulePolicy, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+
+	policy := &RulePolicy{}
+	if err := json.Unmarshal(data, policy); err != nil {
+		return nil, err
+	}
+
+	return policy, nil
+}
+
+func (p *RulePolicy) Allowed(principal *Principal, action Action, owner string) bool {
+	for i := range p.Rules {
+		if p.Rules[i].matches(principal, action, owner) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// NewAuthorizationMiddleware checks the Principal stored in the context against the policy.
+// Requests for a single computation are checked against its owner, computations the Principal
+// may not access are reported as not found like missing ones. Listings are filtered down to the
+// computations the Principal may view.
+// It must be chained after NewAuthenticationMiddleware.
+func NewAuthorizationMiddleware(policy Policy, action Action, svc ComputationServiceIfc) endpoint.Middleware {
+	return func(next endpoint.Endpoint) endpoint.Endpoint {
+		return func(ctx context.Context, request interface{}) (interface{}, error) {
+			principal, ok := PrincipalFromContext(ctx)
+			if !ok {
+				return nil, ErrUnauthenticated
+			}
+
+			switch request.(type) {
+			case GetAllComputationsRequest:
+				response, err := next(ctx, request)
+				if err != nil {
+					return nil, err
+				}
+				return GetAllComputationsResponse{
+					Computations: filterComputations(policy, principal, action, response.(GetAllComputationsResponse).Computations),
+				}, nil
+			case GetQueueRequest:
+				response, err := next(ctx, request)
+				if err != nil {
+					return nil, err
+				}
+				return GetQueueResponse{
+					Computations: filterComputations(policy, principal, action, response.(GetQueueResponse).Computations),
+				}, nil
+			}
+
+			name, ok := requestedComputationName(request)
+			if !ok {
+				if !policy.Allowed(principal, action, "") {
+					return nil, forbidden(principal, action)
+				}
+				return next(ctx, request)
+			}
+
+			// Decided before the computation is looked up, and computations of other users the
+			// principal may not see are reported like missing ones, so that the response does not
+			// tell which computations exist
+			if !policy.Allowed(principal, action, principal.Name) {
+				return nil, forbidden(principal, action)
+			}
+			computation, err := svc.GetComputation(name)
+			if err != nil || !policy.Allowed(principal, action, computation.Owner) {
+				return nil, newStatusError(http.StatusNotFound, "computation %s not found", name)
+			}
+
+			return next(ctx, request)
+		}
+	}
+}
+
+func forbidden(principal *Principal, action Action) error {
+	return newStatusError(ErrForbidden.Code, "%s: %s may not %s computation", ErrForbidden.Message, principal.Name, action)
+}
+
+func filterComputations(policy Policy, principal *Principal, action Action, computations []Computation) []Computation {
+	var visible []Computation
+	for _, computation := range computations {
+		if policy.Allowed(principal, action, computation.Owner) {
+			visible = append(visible, computation)
+		}
+	}
+	return visible
+}
+
+func requestedComputationName(request interface{}) (string, bool) {
+	switch req := request.(type) {
+	case GetComputationRequest:
+		return req.Name, true
+	case GetComputationLogsRequest:
+		return req.Name, true
+	case DeleteComputationRequest:
+		return req.Name, true
+	default:
+		return "", false
+	}
+}
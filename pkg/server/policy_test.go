@@ -0,0 +1,102 @@
+package server
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+)
+
+func TestRulePolicy(t *testing.T) {
+	policy := &RulePolicy{
+		Rules: []Rule{
+			{Users: []string{"*"}, Actions: []Action{ActionCreate, ActionView}},
+			{Users: []string{"carol"}, Actions: []Action{ActionDelete}},
+			{Groups: []string{"admins"}, Actions: []Action{"*"}, AnyOwner: true},
+		},
+	}
+	alice := &Principal{Name: "alice"}
+	carol := &Principal{Name: "carol"}
+	admin := &Principal{Name: "dave", Groups: []string{"admins"}}
+
+	tests := []struct {
+		principal *Principal
+		action    Action
+		owner     string
+		allowed   bool
+	}{
+		{alice, ActionCreate, "", true},
+		{alice, ActionView, "alice", true},
+		{alice, ActionView, "bob", false},
+		{alice, ActionDelete, "alice", false},
+		{carol, ActionDelete, "carol", true},
+		{carol, ActionDelete, "bob", false},
+		{admin, ActionView, "bob", true},
+		{admin, ActionDelete, "bob", true},
+	}
+
+	for _, tt := range tests {
+		if got := policy.Allowed(tt.principal, tt.action, tt.owner); got != tt.allowed {
+			t.Errorf("%s %s computation of %q: got %v, want %v", tt.principal.Name, tt.action, tt.owner, got, tt.allowed)
+		}
+	}
+
+	defaults := NewDefaultPolicy()
+	if !defaults.Allowed(alice, ActionDelete, "alice") || defaults.Allowed(alice, ActionDelete, "bob") {
+		t.Error("default policy does not limit users to their own computations")
+	}
+}
+
+// ownedComputations serves computations by name, the other methods are not used
+type ownedComputations struct {
+	ComputationServiceIfc
+	owners map[string]string
+}
+
+func (s ownedComputations) GetComputation(name string) (*Computation, error) {
+	owner, ok := s.owners[name]
+	if !ok {
+		return nil, errors.New("resource does not exists")
+	}
+	return &Computation{Name: name, Owner: owner}, nil
+}
+
+func TestAuthorizationMiddleware(t *testing.T) {
+	policy := &RulePolicy{
+		Rules: []Rule{
+			{Users: []string{"*"}, Actions: []Action{ActionView}},
+			{Groups: []string{"admins"}, Actions: []Action{"*"}, AnyOwner: true},
+		},
+	}
+	svc := ownedComputations{owners: map[string]string{"alice-job": "alice", "bob-job": "bob"}}
+	next := func(context.Context, interface{}) (interface{}, error) { return "ok", nil }
+
+	alice := &Principal{Name: "alice"}
+	admin := &Principal{Name: "dave", Groups: []string{"admins"}}
+
+	tests := map[string]struct {
+		principal *Principal
+		action    Action
+		name      string
+		code      int
+	}{
+		"own computation":              {alice, ActionView, "alice-job", 0},
+		"computation of another user":  {alice, ActionView, "bob-job", http.StatusNotFound},
+		"missing computation":          {alice, ActionView, "missing", http.StatusNotFound},
+		"action not allowed, existing": {alice, ActionDelete, "alice-job", http.StatusForbidden},
+		"action not allowed, missing":  {alice, ActionDelete, "missing", http.StatusForbidden},
+		"admin":                        {admin, ActionDelete, "bob-job", 0},
+		"admin, missing computation":   {admin, ActionDelete, "missing", http.StatusNotFound},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctx := context.WithValue(context.Background(), principalContextKey, tt.principal)
+			endpoint := NewAuthorizationMiddleware(policy, tt.action, svc)(next)
+			_, err := endpoint(ctx, GetComputationRequest{Name: tt.name})
+			if code := statusCode(err); code != tt.code {
+				t.Errorf("got %v with status %d, want status %d", err, code, tt.code)
+			}
+		})
+	}
+}
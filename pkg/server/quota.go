@@ -0,0 +1,101 @@
+package server
+
+import (
+	"fmt"
+	"net/http"
+
+	glkube "github.com/lnikon/glfs-pkg/pkg/kube"
+	upcxxv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// Quota limits the admitted, unfinished computations and their workers, counted like the
+// quotas of the operator, and the computations waiting for admission. Zero values mean
+// unlimited.
+type Quota struct {
+	MaxComputations int32 `json:"maxComputations"`
+	MaxWorkers      int32 `json:"maxWorkers"`
+
+	// Computations Pending or Queued in the operator, which do not count against the other
+	// limits until they are admitted
+	MaxPending int32 `json:"maxPending"`
+}
+
+type quotaUsage struct {
+	computations int32
+	workers      int32
+	pending      int32
+}
+
+// add counts the computation against the usage
+func (u *quotaUsage) add(upcxx *upcxxv1alpha1.UPCXX) {
+	switch {
+	case upcxx.Status.HoldsQuota():
+		u.computations++
+		u.workers += upcxx.Spec.WorkerCount
+	case upcxx.Status.IsWaiting():
+		u.pending++
+	}
+}
+
+// check fails with 403 when the request can never fit into the quota
+// and with 429 when it does not fit next to the current usage.
+func (q Quota) check(scope string, usage quotaUsage, workerCount int32) error {
+	if q.MaxWorkers > 0 && workerCount > q.MaxWorkers {
+		return newStatusError(http.StatusForbidden,
+			"%s quota exceeded: %d workers requested, at most %d allowed", scope, workerCount, q.MaxWorkers)
+	}
+
+	// New computations wait for admission, so it is the pending limit they have to fit into
+	if q.MaxPending > 0 && usage.pending >= q.MaxPending {
+		return newStatusError(http.StatusTooManyRequests,
+			"%s quota exceeded: %d of %d pending computations, 0 remaining", scope, usage.pending, q.MaxPending)
+	}
+
+	if q.MaxComputations > 0 && usage.computations >= q.MaxComputations {
+		return newStatusError(http.StatusTooManyRequests,
+			"%s quota exceeded: %d of %d computations in use, 0 remaining", scope, usage.computations, q.MaxComputations)
+	}
+
+	if q.MaxWorkers > 0 && usage.workers+workerCount > q.MaxWorkers {
+		return newStatusError(http.StatusTooManyRequests,
+			"%s quota exceeded: %d workers requested, %d of %d in use, %d remaining",
+			scope, workerCount, usage.workers, q.MaxWorkers, q.MaxWorkers-usage.workers)
+	}
+
+	return nil
+}
+
+func (c *ComputationService) checkQuotas(owner string, workerCount int32) error {
+	if c.globalQuota == (Quota{}) && (c.userQuota == (Quota{}) || owner == "") {
+		return nil
+	}
+
+	upcxxList := glkube.GetAllDeployments()
+	if upcxxList == nil {
+		return fmt.Errorf("unable to list computations for quota check")
+	}
+
+	global, user := usageOf(upcxxList.Items, owner)
+
+	if err := c.globalQuota.check("global", global, workerCount); err != nil {
+		return err
+	}
+
+	if owner != "" {
+		return c.userQuota.check(fmt.Sprintf("user %s", owner), user, workerCount)
+	}
+
+	return nil
+}
+
+// usageOf sums up the usage of all computations and of the computations of owner
+func usageOf(upcxxes []upcxxv1alpha1.UPCXX, owner string) (global, user quotaUsage) {
+	for i := range upcxxes {
+		upcxx := &upcxxes[i]
+		global.add(upcxx)
+		if owner != "" && upcxx.Annotations[upcxxv1alpha1.OwnerAnnotation] == owner {
+			user.add(upcxx)
+		}
+	}
+	return global, user
+}
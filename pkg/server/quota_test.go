@@ -0,0 +1,67 @@
+package server
+
+import (
+	"net/http"
+	"testing"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	upcxxv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+func newOwnedUPCXX(owner string, phase upcxxv1alpha1.UPCXXPhase, workers int32) upcxxv1alpha1.UPCXX {
+	return upcxxv1alpha1.UPCXX{
+		ObjectMeta: meta.ObjectMeta{Annotations: map[string]string{upcxxv1alpha1.OwnerAnnotation: owner}},
+		Spec:       upcxxv1alpha1.UPCXXSpec{WorkerCount: workers},
+		Status:     upcxxv1alpha1.UPCXXStatus{Phase: phase},
+	}
+}
+
+func TestUsageOf(t *testing.T) {
+	upcxxes := []upcxxv1alpha1.UPCXX{
+		newOwnedUPCXX("alice", upcxxv1alpha1.UPCXXRunning, 4),
+		newOwnedUPCXX("alice", upcxxv1alpha1.UPCXXPending, 2),
+		newOwnedUPCXX("alice", upcxxv1alpha1.UPCXXQueued, 2),
+		newOwnedUPCXX("alice", "", 2),
+		newOwnedUPCXX("alice", upcxxv1alpha1.UPCXXSucceeded, 8),
+		newOwnedUPCXX("bob", upcxxv1alpha1.UPCXXRunning, 1),
+		newOwnedUPCXX("bob", upcxxv1alpha1.UPCXXQueued, 1),
+	}
+
+	global, user := usageOf(upcxxes, "alice")
+	if want := (quotaUsage{computations: 2, workers: 5, pending: 4}); global != want {
+		t.Errorf("global usage %+v, want %+v", global, want)
+	}
+	if want := (quotaUsage{computations: 1, workers: 4, pending: 3}); user != want {
+		t.Errorf("user usage %+v, want %+v", user, want)
+	}
+}
+
+func TestQuotaCheck(t *testing.T) {
+	quota := Quota{MaxComputations: 2, MaxWorkers: 8, MaxPending: 3}
+
+	tests := map[string]struct {
+		usage   quotaUsage
+		workers int32
+		code    int
+	}{
+		"fits":                  {quotaUsage{computations: 1, workers: 4, pending: 2}, 4, 0},
+		"too many pending":      {quotaUsage{pending: 3}, 1, http.StatusTooManyRequests},
+		"too many computations": {quotaUsage{computations: 2, workers: 2}, 1, http.StatusTooManyRequests},
+		"too many workers":      {quotaUsage{computations: 1, workers: 6}, 4, http.StatusTooManyRequests},
+		"never fits":            {quotaUsage{}, 9, http.StatusForbidden},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := quota.check("user alice", tt.usage, tt.workers)
+			if code := statusCode(err); code != tt.code {
+				t.Errorf("got %v with status %d, want status %d", err, code, tt.code)
+			}
+		})
+	}
+
+	if err := (Quota{}).check("global", quotaUsage{computations: 100, workers: 100, pending: 100}, 100); err != nil {
+		t.Errorf("empty quota: %v", err)
+	}
+}
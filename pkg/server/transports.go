@@ -3,12 +3,16 @@ package server
 import (
 	"context"
 	"encoding/json"
+	"io"
 	"net/http"
+	"strconv"
 
 	"github.com/go-kit/kit/endpoint"
 	"github.com/gorilla/mux"
 
 	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	glkube "github.com/lnikon/glfs-pkg/pkg/kube"
+	upcxxv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
 )
 
 // /algorithm endpoint
@@ -16,7 +20,7 @@ type AlgorithmRequest struct {
 }
 
 type algorithmResponse struct {
-	Algorithm []glconstants.Algorithm
+	Algorithms []glconstants.AlgorithmSpec
 }
 
 func MakeAlgorithmEndpoint(svc *AlgorithmService) endpoint.Endpoint {
@@ -74,23 +78,86 @@ func DecodeGetAllComputationsRequest(_ context.Context, r *http.Request) (interf
 	return GetAllComputationsRequest{}, nil
 }
 
+type GetQueueRequest struct {
+}
+
+type GetQueueResponse struct {
+	Computations []Computation
+}
+
+func MakeGetQueueEndpoint(svc ComputationServiceIfc) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		return GetQueueResponse{Computations: svc.GetQueue()}, nil
+	}
+}
+
+func DecodeGetQueueRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return GetQueueRequest{}, nil
+}
+
 // Universal encoder for all responses
 func EncodeResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	if rendered, ok := response.(RenderComputationResponse); ok {
+		w.Header().Set("Content-Type", "application/yaml")
+		_, err := w.Write(rendered.Manifests)
+		return err
+	}
+
 	return json.NewEncoder(w).Encode(response)
 }
 
 type PostComputationRequest struct {
-	Algorithm glconstants.Algorithm
+	Algorithm   glconstants.Algorithm
+	Parameters  map[string]string
+	WorkerCount int32
+	Priority    int32
+	RetainData  bool
+
+	// Verify the result against the reference implementation of the algorithm
+	Verify *upcxxv1alpha1.VerifySpec
+
+	// Graph the algorithm runs on
+	Input *InputGraph
+
+	// Render the objects of the computation instead of creating it
+	DryRun bool
 }
 
 type PostComputationResponse struct {
 	Computation *Computation
 }
 
+// RenderComputationResponse holds the YAML manifests of a computation created with dryRun
+type RenderComputationResponse struct {
+	Manifests []byte
+}
+
 func MakePostComputationEndpoint(svc ComputationServiceIfc) endpoint.Endpoint {
-	return func(_ context.Context, request interface{}) (interface{}, error) {
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
 		req := request.(PostComputationRequest)
-		computation, err := svc.PostComputation(req.Algorithm)
+		opts := ComputationOptions{
+			Algorithm:   req.Algorithm,
+			Parameters:  req.Parameters,
+			WorkerCount: req.WorkerCount,
+			Priority:    req.Priority,
+			RetainData:  req.RetainData,
+			Verify:      req.Verify,
+			Input:       req.Input,
+		}
+		if principal, ok := PrincipalFromContext(ctx); ok {
+			opts.Owner = principal.Name
+		}
+
+		if req.DryRun {
+			manifests, err := svc.RenderComputation(opts)
+			if err != nil {
+				return nil, err
+			}
+
+			return RenderComputationResponse{Manifests: manifests}, nil
+		}
+
+		computation, err := svc.PostComputation(opts)
 		if err != nil {
 			return nil, err
 		}
@@ -101,14 +168,157 @@ func MakePostComputationEndpoint(svc ComputationServiceIfc) endpoint.Endpoint {
 
 func DecodePostComputationRequest(_ context.Context, r *http.Request) (interface{}, error) {
 	var body struct {
-		Algorithm glconstants.Algorithm `json:"algorithm"`
+		Algorithm   glconstants.Algorithm     `json:"algorithm"`
+		Parameters  map[string]string         `json:"parameters"`
+		WorkerCount int32                     `json:"workerCount"`
+		Priority    int32                     `json:"priority"`
+		RetainData  bool                      `json:"retainData"`
+		Verify      *upcxxv1alpha1.VerifySpec `json:"verify"`
+		Input       *InputGraph               `json:"input"`
 	}
 
-	if err := json.NewDecoder(r.Body).Decode(&body); err != nil {
-		return nil, err
+	// The body carries the uploaded graph, which must not be read into memory without a bound
+	if err := json.NewDecoder(http.MaxBytesReader(nil, r.Body, maxRequestBodySize)).Decode(&body); err != nil {
+		return nil, newStatusError(http.StatusBadRequest, "invalid request body: %v", err)
+	}
+
+	if body.WorkerCount < 0 {
+		return nil, newStatusError(http.StatusBadRequest, "workerCount must not be negative")
+	}
+
+	if body.Priority > MaxComputationPriority {
+		return nil, newStatusError(http.StatusBadRequest, "priority must not exceed %d", MaxComputationPriority)
+	}
+
+	var dryRun bool
+	if value := r.URL.Query().Get("dryRun"); value != "" {
+		var err error
+		if dryRun, err = strconv.ParseBool(value); err != nil {
+			return nil, newStatusError(http.StatusBadRequest, "dryRun must be a boolean")
+		}
 	}
 
 	return PostComputationRequest{
-		Algorithm: body.Algorithm,
+		Algorithm:   body.Algorithm,
+		Parameters:  body.Parameters,
+		WorkerCount: body.WorkerCount,
+		Priority:    body.Priority,
+		RetainData:  body.RetainData,
+		Verify:      body.Verify,
+		Input:       body.Input,
+		DryRun:      dryRun,
 	}, nil
 }
+
+type DeleteComputationRequest struct {
+	Name string
+}
+
+type DeleteComputationResponse struct {
+}
+
+func MakeDeleteComputationEndpoint(svc ComputationServiceIfc) endpoint.Endpoint {
+	return func(_ context.Context, request interface{}) (interface{}, error) {
+		req := request.(DeleteComputationRequest)
+		if err := svc.DeleteComputation(req.Name); err != nil {
+			return nil, err
+		}
+
+		return DeleteComputationResponse{}, nil
+	}
+}
+
+func DecodeDeleteComputationRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	return DeleteComputationRequest{
+		Name: mux.Vars(r)["name"],
+	}, nil
+}
+
+// /computations/{name}/logs endpoint
+type GetComputationLogsRequest struct {
+	Name    string
+	Options glkube.LogOptions
+}
+
+type GetComputationLogsResponse struct {
+	Logs io.ReadCloser
+}
+
+func MakeGetComputationLogsEndpoint(svc ComputationServiceIfc) endpoint.Endpoint {
+	// The context of the request is cancelled when the client disconnects, ending followed streams
+	return func(ctx context.Context, request interface{}) (interface{}, error) {
+		req := request.(GetComputationLogsRequest)
+		logs, err := svc.GetComputationLogs(ctx, req.Name, req.Options)
+		if err != nil {
+			return nil, err
+		}
+
+		return GetComputationLogsResponse{Logs: logs}, nil
+	}
+}
+
+func DecodeGetComputationLogsRequest(_ context.Context, r *http.Request) (interface{}, error) {
+	query := r.URL.Query()
+	options := glkube.LogOptions{
+		Pod:  query.Get("pod"),
+		Role: query.Get("role"),
+	}
+
+	if follow := query.Get("follow"); follow != "" {
+		value, err := strconv.ParseBool(follow)
+		if err != nil {
+			return nil, err
+		}
+		options.Follow = value
+	}
+
+	if tailLines := query.Get("tailLines"); tailLines != "" {
+		value, err := strconv.ParseInt(tailLines, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		options.TailLines = &value
+	}
+
+	if sinceSeconds := query.Get("sinceSeconds"); sinceSeconds != "" {
+		value, err := strconv.ParseInt(sinceSeconds, 10, 64)
+		if err != nil {
+			return nil, err
+		}
+		options.SinceSeconds = &value
+	}
+
+	return GetComputationLogsRequest{
+		Name:    mux.Vars(r)["name"],
+		Options: options,
+	}, nil
+}
+
+// EncodeLogsResponse streams the logs to the client, flushing after every chunk
+// so that followed logs show up as they are produced.
+func EncodeLogsResponse(_ context.Context, w http.ResponseWriter, response interface{}) error {
+	logs := response.(GetComputationLogsResponse).Logs
+	defer logs.Close()
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	flusher, _ := w.(http.Flusher)
+
+	buf := make([]byte, 4096)
+	for {
+		n, err := logs.Read(buf)
+		if n > 0 {
+			if _, werr := w.Write(buf[:n]); werr != nil {
+				return werr
+			}
+			if flusher != nil {
+				flusher.Flush()
+			}
+		}
+		if err == io.EOF {
+			return nil
+		}
+		if err != nil {
+			return err
+		}
+	}
+}
@@ -0,0 +1,43 @@
+package server
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+)
+
+func TestDecodePostComputationRequest(t *testing.T) {
+	tests := map[string]struct {
+		body     string
+		wantCode int
+	}{
+		"default priority":  {body: `{"algorithm": "kruskal"}`},
+		"lowered priority":  {body: `{"algorithm": "kruskal", "priority": -5}`},
+		"highest priority":  {body: `{"algorithm": "kruskal", "priority": 100}`},
+		"priority too high": {body: `{"algorithm": "kruskal", "priority": 101}`, wantCode: http.StatusBadRequest},
+		"negative workers":  {body: `{"algorithm": "kruskal", "workerCount": -1}`, wantCode: http.StatusBadRequest},
+		"malformed body":    {body: `{"algorithm": `, wantCode: http.StatusBadRequest},
+		"oversized body": {
+			body:     `{"algorithm": "kruskal", "input": {"data": "` + strings.Repeat(`0 1\n`, maxRequestBodySize/5) + `"}}`,
+			wantCode: http.StatusBadRequest,
+		},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			r := httptest.NewRequest(http.MethodPost, "/computations", strings.NewReader(tt.body))
+			_, err := DecodePostComputationRequest(context.Background(), r)
+			if tt.wantCode == 0 {
+				if err != nil {
+					t.Fatalf("DecodePostComputationRequest() error = %v", err)
+				}
+				return
+			}
+			if code := statusCode(err); code != tt.wantCode {
+				t.Fatalf("DecodePostComputationRequest() error = %v, want status %d", err, tt.wantCode)
+			}
+		})
+	}
+}
@@ -0,0 +1,245 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"encoding/json"
+	"fmt"
+
+	core "k8s.io/api/core/v1"
+	"sigs.k8s.io/controller-runtime/pkg/conversion"
+
+	"github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1beta1"
+)
+
+const (
+	// statefulSetNameAnnotation keeps the deprecated spec.statefulSetName while the object is
+	// stored as v1beta1
+	statefulSetNameAnnotation = "pgas.github.com/v1alpha1-statefulset-name"
+
+	// conversionDataAnnotation keeps the v1beta1 fields v1alpha1 has no place for, so that
+	// updating the object through v1alpha1 does not drop them
+	conversionDataAnnotation = "pgas.github.com/v1beta1-data"
+)
+
+// conversionData holds the v1beta1 fields missing in v1alpha1
+type conversionData struct {
+	Input     *v1beta1.DataSource        `json:"input,omitempty"`
+	Output    *v1beta1.DataSink          `json:"output,omitempty"`
+	Resources *core.ResourceRequirements `json:"resources,omitempty"`
+}
+
+// ConvertTo converts this UPCXX to the hub version
+func (src *UPCXX) ConvertTo(dstRaw conversion.Hub) error {
+	dst := dstRaw.(*v1beta1.UPCXX)
+	in := src.DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+	if in.Spec.StatefulSetName != "" {
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[statefulSetNameAnnotation] = in.Spec.StatefulSetName
+	}
+
+	dst.Spec = v1beta1.UPCXXSpec{
+		Algorithm:  in.Spec.Algorithm,
+		Parameters: in.Spec.Parameters,
+		Priority:   in.Spec.Priority,
+		Workers: v1beta1.WorkersSpec{
+			Count:            in.Spec.WorkerCount,
+			RanksPerPod:      in.Spec.RanksPerPod,
+			LauncherIsWorker: in.Spec.LauncherIsWorker,
+		},
+		RunPolicy: v1beta1.RunPolicy{
+			ActiveDeadlineSeconds:   in.Spec.ActiveDeadlineSeconds,
+			BackoffLimit:            in.Spec.BackoffLimit,
+			RestartPolicy:           v1beta1.RestartPolicy(in.Spec.RestartPolicy),
+			MaxRestarts:             in.Spec.MaxRestarts,
+			TTLSecondsAfterFinished: in.Spec.TTLSecondsAfterFinished,
+			RetainData:              in.Spec.RetainData,
+		},
+	}
+
+	if in.Spec.Storage != nil {
+		dst.Spec.Storage = &v1beta1.StorageSpec{
+			Mode:             v1beta1.StorageMode(in.Spec.Storage.Mode),
+			Size:             in.Spec.Storage.Size,
+			StorageClassName: in.Spec.Storage.StorageClassName,
+			AccessMode:       in.Spec.Storage.AccessMode,
+			MountPath:        in.Spec.Storage.MountPath,
+		}
+	}
+	if in.Spec.Network != nil {
+		dst.Spec.Network = &v1beta1.NetworkSpec{
+			LaunchMode: v1beta1.LaunchMode(in.Spec.Network.LaunchMode),
+			Conduit:    v1beta1.GASNetConduit(in.Spec.Network.Conduit),
+			Spawner:    v1beta1.GASNetSpawner(in.Spec.Network.Spawner),
+			Env:        in.Spec.Network.Env,
+		}
+	}
+	if in.Spec.GangScheduling != nil {
+		dst.Spec.GangScheduling = &v1beta1.GangSchedulingSpec{
+			SchedulerName:          in.Spec.GangScheduling.SchedulerName,
+			PodGroupKind:           v1beta1.PodGroupKind(in.Spec.GangScheduling.PodGroupKind),
+			Queue:                  in.Spec.GangScheduling.Queue,
+			ScheduleTimeoutSeconds: in.Spec.GangScheduling.ScheduleTimeoutSeconds,
+		}
+	}
+	if in.Spec.Verify != nil {
+		dst.Spec.Verify = (*v1beta1.VerifySpec)(in.Spec.Verify)
+	}
+	if in.Spec.Input != nil {
+		configMap := in.Spec.Input.ConfigMap
+		dst.Spec.Input = &v1beta1.DataSource{ConfigMap: &configMap, Format: in.Spec.Input.Format, Directed: in.Spec.Input.Directed}
+	}
+
+	if raw, ok := dst.Annotations[conversionDataAnnotation]; ok {
+		data := conversionData{}
+		if err := json.Unmarshal([]byte(raw), &data); err != nil {
+			return fmt.Errorf("restoring v1beta1 fields of UPCXX %s: %w", in.Name, err)
+		}
+		// An input set through v1alpha1 replaces the one kept from v1beta1
+		if data.Input != nil && dst.Spec.Input == nil {
+			dst.Spec.Input = data.Input
+		}
+		dst.Spec.Output = data.Output
+		if data.Resources != nil {
+			dst.Spec.Workers.Resources = *data.Resources
+		}
+		delete(dst.Annotations, conversionDataAnnotation)
+	}
+
+	dst.Status = v1beta1.UPCXXStatus{
+		Phase:          v1beta1.UPCXXPhase(in.Status.Phase),
+		StartTime:      in.Status.StartTime,
+		CompletionTime: in.Status.CompletionTime,
+		QueuePosition:  in.Status.QueuePosition,
+		Ranks:          in.Status.Ranks,
+		Restarts:       in.Status.Restarts,
+		Conditions:     in.Status.Conditions,
+	}
+	for _, attempt := range in.Status.Attempts {
+		dst.Status.Attempts = append(dst.Status.Attempts, v1beta1.UPCXXAttempt(attempt))
+	}
+	if in.Status.Verification != nil {
+		dst.Status.Verification = &v1beta1.VerificationStatus{
+			Verdict:        v1beta1.VerificationVerdict(in.Status.Verification.Verdict),
+			Message:        in.Status.Verification.Message,
+			CompletionTime: in.Status.Verification.CompletionTime,
+		}
+	}
+
+	return nil
+}
+
+// ConvertFrom converts from the hub version to this UPCXX
+func (dst *UPCXX) ConvertFrom(srcRaw conversion.Hub) error {
+	in := srcRaw.(*v1beta1.UPCXX).DeepCopy()
+
+	dst.ObjectMeta = in.ObjectMeta
+	statefulSetName := dst.Annotations[statefulSetNameAnnotation]
+	delete(dst.Annotations, statefulSetNameAnnotation)
+
+	dst.Spec = UPCXXSpec{
+		StatefulSetName:         statefulSetName,
+		WorkerCount:             in.Spec.Workers.Count,
+		RanksPerPod:             in.Spec.Workers.RanksPerPod,
+		LauncherIsWorker:        in.Spec.Workers.LauncherIsWorker,
+		Algorithm:               in.Spec.Algorithm,
+		Parameters:              in.Spec.Parameters,
+		Priority:                in.Spec.Priority,
+		ActiveDeadlineSeconds:   in.Spec.RunPolicy.ActiveDeadlineSeconds,
+		BackoffLimit:            in.Spec.RunPolicy.BackoffLimit,
+		RestartPolicy:           RestartPolicy(in.Spec.RunPolicy.RestartPolicy),
+		MaxRestarts:             in.Spec.RunPolicy.MaxRestarts,
+		TTLSecondsAfterFinished: in.Spec.RunPolicy.TTLSecondsAfterFinished,
+		RetainData:              in.Spec.RunPolicy.RetainData,
+	}
+
+	if in.Spec.Storage != nil {
+		dst.Spec.Storage = &StorageSpec{
+			Mode:             StorageMode(in.Spec.Storage.Mode),
+			Size:             in.Spec.Storage.Size,
+			StorageClassName: in.Spec.Storage.StorageClassName,
+			AccessMode:       in.Spec.Storage.AccessMode,
+			MountPath:        in.Spec.Storage.MountPath,
+		}
+	}
+	if in.Spec.Network != nil {
+		dst.Spec.Network = &NetworkSpec{
+			LaunchMode: LaunchMode(in.Spec.Network.LaunchMode),
+			Conduit:    GASNetConduit(in.Spec.Network.Conduit),
+			Spawner:    GASNetSpawner(in.Spec.Network.Spawner),
+			Env:        in.Spec.Network.Env,
+		}
+	}
+	if in.Spec.GangScheduling != nil {
+		dst.Spec.GangScheduling = &GangSchedulingSpec{
+			SchedulerName:          in.Spec.GangScheduling.SchedulerName,
+			PodGroupKind:           PodGroupKind(in.Spec.GangScheduling.PodGroupKind),
+			Queue:                  in.Spec.GangScheduling.Queue,
+			ScheduleTimeoutSeconds: in.Spec.GangScheduling.ScheduleTimeoutSeconds,
+		}
+	}
+	if in.Spec.Verify != nil {
+		dst.Spec.Verify = (*VerifySpec)(in.Spec.Verify)
+	}
+
+	// Only inputs read from a ConfigMap have a place in v1alpha1
+	data := conversionData{Output: in.Spec.Output}
+	if input := in.Spec.Input; input != nil && input.ConfigMap != nil && input.PersistentVolumeClaim == nil && input.URL == "" {
+		dst.Spec.Input = &InputSpec{ConfigMap: *input.ConfigMap, Format: input.Format, Directed: input.Directed}
+	} else {
+		data.Input = input
+	}
+	if resources := in.Spec.Workers.Resources; len(resources.Limits) > 0 || len(resources.Requests) > 0 {
+		data.Resources = &resources
+	}
+	if data != (conversionData{}) {
+		raw, err := json.Marshal(data)
+		if err != nil {
+			return fmt.Errorf("keeping v1beta1 fields of UPCXX %s: %w", in.Name, err)
+		}
+		if dst.Annotations == nil {
+			dst.Annotations = map[string]string{}
+		}
+		dst.Annotations[conversionDataAnnotation] = string(raw)
+	}
+
+	dst.Status = UPCXXStatus{
+		Phase:          UPCXXPhase(in.Status.Phase),
+		StartTime:      in.Status.StartTime,
+		CompletionTime: in.Status.CompletionTime,
+		QueuePosition:  in.Status.QueuePosition,
+		Ranks:          in.Status.Ranks,
+		Restarts:       in.Status.Restarts,
+		Conditions:     in.Status.Conditions,
+	}
+	for _, attempt := range in.Status.Attempts {
+		dst.Status.Attempts = append(dst.Status.Attempts, UPCXXAttempt(attempt))
+	}
+	if in.Status.Verification != nil {
+		dst.Status.Verification = &VerificationStatus{
+			Verdict:        VerificationVerdict(in.Status.Verification.Verdict),
+			Message:        in.Status.Verification.Message,
+			CompletionTime: in.Status.Verification.CompletionTime,
+		}
+	}
+
+	return nil
+}
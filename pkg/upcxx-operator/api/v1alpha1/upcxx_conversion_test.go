@@ -0,0 +1,175 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1alpha1
+
+import (
+	"testing"
+
+	fuzz "github.com/google/gofuzz"
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/equality"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/diff"
+
+	"github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1beta1"
+)
+
+const fuzzIterations = 1000
+
+func newFuzzer(seed int64) *fuzz.Fuzzer {
+	return fuzz.NewWithSeed(seed).NilChance(0.3).Funcs(
+		func(q *resource.Quantity, c fuzz.Continue) {
+			*q = *resource.NewQuantity(c.Int63n(1<<20), resource.DecimalSI)
+		},
+		// The conversion does not touch the kind, it is set by the webhook
+		func(t *metav1.TypeMeta, c fuzz.Continue) {},
+	)
+}
+
+func TestUPCXXRoundTripFromSpoke(t *testing.T) {
+	fuzzer := newFuzzer(1)
+	for i := 0; i < fuzzIterations; i++ {
+		original := &UPCXX{}
+		fuzzer.Fuzz(original)
+
+		hub := &v1beta1.UPCXX{}
+		if err := original.DeepCopy().ConvertTo(hub); err != nil {
+			t.Fatalf("converting to v1beta1: %v", err)
+		}
+		converted := &UPCXX{}
+		if err := converted.ConvertFrom(hub); err != nil {
+			t.Fatalf("converting from v1beta1: %v", err)
+		}
+
+		if !equality.Semantic.DeepEqual(original, converted) {
+			t.Fatalf("v1alpha1 -> v1beta1 -> v1alpha1 is lossy, diff: %s", diff.ObjectReflectDiff(original, converted))
+		}
+	}
+}
+
+func TestUPCXXRoundTripFromHub(t *testing.T) {
+	fuzzer := newFuzzer(2)
+	for i := 0; i < fuzzIterations; i++ {
+		original := &v1beta1.UPCXX{}
+		fuzzer.Fuzz(original)
+
+		spoke := &UPCXX{}
+		if err := spoke.ConvertFrom(original.DeepCopy()); err != nil {
+			t.Fatalf("converting from v1beta1: %v", err)
+		}
+		converted := &v1beta1.UPCXX{}
+		if err := spoke.ConvertTo(converted); err != nil {
+			t.Fatalf("converting to v1beta1: %v", err)
+		}
+
+		if !equality.Semantic.DeepEqual(original, converted) {
+			t.Fatalf("v1beta1 -> v1alpha1 -> v1beta1 is lossy, diff: %s", diff.ObjectReflectDiff(original, converted))
+		}
+	}
+}
+
+func TestUPCXXConvertStatefulSetName(t *testing.T) {
+	upcxx := &UPCXX{
+		ObjectMeta: metav1.ObjectMeta{Name: "job"},
+		Spec:       UPCXXSpec{WorkerCount: 2},
+	}
+
+	hub := &v1beta1.UPCXX{}
+	if err := upcxx.ConvertTo(hub); err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := hub.Annotations[statefulSetNameAnnotation]; ok {
+		t.Errorf("unset statefulSetName is kept in an annotation")
+	}
+	if hub.Spec.Workers.Count != 2 {
+		t.Errorf("workers.count = %d, want 2", hub.Spec.Workers.Count)
+	}
+
+	upcxx.Spec.StatefulSetName = "legacy"
+	if err := upcxx.ConvertTo(hub); err != nil {
+		t.Fatal(err)
+	}
+	if got := hub.Annotations[statefulSetNameAnnotation]; got != "legacy" {
+		t.Errorf("statefulSetName annotation = %q, want legacy", got)
+	}
+}
+
+func TestUPCXXConvertInput(t *testing.T) {
+	configMap := &core.ConfigMapKeySelector{
+		LocalObjectReference: core.LocalObjectReference{Name: "graphs"},
+		Key:                  "graph.mtx",
+	}
+	hub := &v1beta1.UPCXX{
+		ObjectMeta: metav1.ObjectMeta{Name: "job"},
+		Spec: v1beta1.UPCXXSpec{
+			Input: &v1beta1.DataSource{ConfigMap: configMap, Format: "mtx", Directed: true},
+		},
+	}
+
+	upcxx := &UPCXX{}
+	if err := upcxx.ConvertFrom(hub); err != nil {
+		t.Fatal(err)
+	}
+	if upcxx.Spec.Input == nil || upcxx.Spec.Input.ConfigMap != *configMap || upcxx.Spec.Input.Format != "mtx" || !upcxx.Spec.Input.Directed {
+		t.Errorf("input = %+v, want the ConfigMap of the hub", upcxx.Spec.Input)
+	}
+	if _, ok := upcxx.Annotations[conversionDataAnnotation]; ok {
+		t.Errorf("ConfigMap input is kept in an annotation")
+	}
+
+	hub.Spec.Input = &v1beta1.DataSource{URL: "https://example.com/graph.mtx"}
+	if err := upcxx.ConvertFrom(hub); err != nil {
+		t.Fatal(err)
+	}
+	if upcxx.Spec.Input != nil {
+		t.Errorf("URL input converted to %+v", upcxx.Spec.Input)
+	}
+	if _, ok := upcxx.Annotations[conversionDataAnnotation]; !ok {
+		t.Errorf("URL input is not kept in an annotation")
+	}
+}
+
+func TestUPCXXConvertEditedInput(t *testing.T) {
+	hub := &v1beta1.UPCXX{
+		ObjectMeta: metav1.ObjectMeta{Name: "job"},
+		Spec: v1beta1.UPCXXSpec{
+			Input: &v1beta1.DataSource{URL: "https://example.com/graph.mtx"},
+		},
+	}
+
+	upcxx := &UPCXX{}
+	if err := upcxx.ConvertFrom(hub); err != nil {
+		t.Fatal(err)
+	}
+
+	// An input set through v1alpha1 wins over the one kept in the annotation
+	upcxx.Spec.Input = &InputSpec{ConfigMap: core.ConfigMapKeySelector{
+		LocalObjectReference: core.LocalObjectReference{Name: "graphs"},
+		Key:                  "graph.mtx",
+	}}
+	converted := &v1beta1.UPCXX{}
+	if err := upcxx.ConvertTo(converted); err != nil {
+		t.Fatal(err)
+	}
+	if input := converted.Spec.Input; input == nil || input.ConfigMap == nil || input.URL != "" {
+		t.Errorf("input = %+v, want the ConfigMap set through v1alpha1", input)
+	}
+	if _, ok := converted.Annotations[conversionDataAnnotation]; ok {
+		t.Errorf("annotation is kept in v1beta1")
+	}
+}
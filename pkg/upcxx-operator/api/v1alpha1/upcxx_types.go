@@ -17,11 +17,52 @@ limitations under the License.
 package v1alpha1
 
 import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
 
 	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
 )
 
+const (
+	// OwnerLabel holds a label-safe form of the name of the user who created the UPCXX
+	OwnerLabel = "pgas.github.com/owner"
+
+	// OwnerAnnotation holds the verbatim name of the user who created the UPCXX
+	OwnerAnnotation = "pgas.github.com/owner"
+
+	// UPCXXLabel holds the UPCXXLabelValue of the name of the UPCXX on the objects belonging to
+	// it, including worker PVCs and result artifacts which cannot carry an OwnerReference
+	UPCXXLabel = "pgas.github.com/upcxx"
+
+	// UPCXXAnnotation holds the verbatim name of the UPCXX on its pods, which may not fit
+	// into the UPCXXLabel
+	UPCXXAnnotation = "pgas.github.com/upcxx"
+
+	// Length of the hash telling apart the label values of UPCXXes sharing a long prefix
+	labelValueHashLength = 8
+)
+
+// UPCXXLabelValue converts the name of a UPCXX into the value of its UPCXXLabel. Names longer
+// than a label value are truncated, and a hash of the full name is appended so that UPCXXes
+// sharing a long prefix do not collide.
+func UPCXXLabelValue(name string) string {
+	if len(name) <= validation.LabelValueMaxLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(name))
+	hash := hex.EncodeToString(sum[:])[:labelValueHashLength]
+
+	prefix := strings.TrimRight(name[:validation.LabelValueMaxLength-labelValueHashLength-1], "-.")
+	return prefix + "-" + hash
+}
+
 // EDIT THIS FILE!  THIS IS SCAFFOLDING FOR YOU TO OWN!
 // NOTE: json tags are required.  Any new fields you add must have json tags for the fields to be serialized.
 
@@ -30,25 +71,454 @@ type UPCXXSpec struct {
 	// INSERT ADDITIONAL SPEC FIELDS - desired state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
 
-	// Name of the current UPCXX job deployment
-	StatefulSetName string `json:"statefulSetName"`
+	// Deprecated: ignored, the names of the child objects are derived from the name of the UPCXX.
+	// Kept so that existing manifests stay valid. Children created under a different
+	// statefulSetName by older operators are not adopted, see the upgrade notes in the README.
+	// +optional
+	StatefulSetName string `json:"statefulSetName,omitempty"`
 
-	// Count of worker pods
+	// Count of pods running ranks of the job, including the launcher when launcherIsWorker is set
+	// +kubebuilder:validation:Minimum=1
 	WorkerCount int32 `json:"workerCount"`
 
+	// Number of ranks started in every pod running ranks. Defaults to 1.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	RanksPerPod *int32 `json:"ranksPerPod,omitempty"`
+
+	// Whether the launcher pod runs ranks too, taking the place of one of the workers.
+	// Defaults to true. When false, the launcher only starts the ranks on workerCount workers.
+	// +optional
+	LauncherIsWorker *bool `json:"launcherIsWorker,omitempty"`
+
 	// Algorithm used for the execution
 	Algorithm glconstants.Algorithm `json:"algorithm"`
+
+	// Parameters of the algorithm, checked against the parameter schema of the algorithm
+	// +optional
+	Parameters map[string]string `json:"parameters,omitempty"`
+
+	// Priority of the job in the admission queue. Jobs with higher priority are admitted first.
+	// +optional
+	Priority int32 `json:"priority,omitempty"`
+
+	// Seconds the launcher may run before the job is failed
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ActiveDeadlineSeconds *int64 `json:"activeDeadlineSeconds,omitempty"`
+
+	// Number of launcher retries before the job is failed. Defaults to 1.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	BackoffLimit *int32 `json:"backoffLimit,omitempty"`
+
+	// What to do when the launcher or a worker fails while the job runs. Defaults to Never.
+	// +kubebuilder:default=Never
+	// +optional
+	RestartPolicy RestartPolicy `json:"restartPolicy,omitempty"`
+
+	// Number of times the whole job is restarted before it is failed. Defaults to 3.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	MaxRestarts *int32 `json:"maxRestarts,omitempty"`
+
+	// Seconds after the job finished when its launcher, workers and other child objects
+	// are deleted. The UPCXX itself and its status are kept. Children are kept forever when unset.
+	// +kubebuilder:validation:Minimum=0
+	// +optional
+	TTLSecondsAfterFinished *int32 `json:"ttlSecondsAfterFinished,omitempty"`
+
+	// Storage mounted into the launcher and worker pods
+	// +optional
+	Storage *StorageSpec `json:"storage,omitempty"`
+
+	// GASNet conduit and spawner used by the job
+	// +optional
+	Network *NetworkSpec `json:"network,omitempty"`
+
+	// Keep worker PVCs and result artifacts when the UPCXX is deleted
+	// +optional
+	RetainData bool `json:"retainData,omitempty"`
+
+	// Schedule all worker pods at once through a gang scheduler
+	// +optional
+	GangScheduling *GangSchedulingSpec `json:"gangScheduling,omitempty"`
+
+	// Check the result of the succeeded job against the reference implementation of its algorithm
+	// +optional
+	Verify *VerifySpec `json:"verify,omitempty"`
+
+	// Input graph of the job, mounted into the launcher and worker pods
+	// +optional
+	Input *InputSpec `json:"input,omitempty"`
+}
+
+// RestartPolicy selects whether a failed job is restarted by recreating its launcher and workers
+// +kubebuilder:validation:Enum=Never;OnFailure;ExitCode
+type RestartPolicy string
+
+const (
+	// RestartPolicyNever fails the job on the first failure of the launcher or a worker
+	RestartPolicyNever RestartPolicy = "Never"
+
+	// RestartPolicyOnFailure restarts the job on every failure, up to maxRestarts times
+	RestartPolicyOnFailure RestartPolicy = "OnFailure"
+
+	// RestartPolicyExitCode restarts the job, up to maxRestarts times, only when the failed
+	// container was killed by a signal (exit codes 128-255), e.g. when it ran out of memory.
+	// Other exit codes are considered permanent errors of the application.
+	RestartPolicyExitCode RestartPolicy = "ExitCode"
+)
+
+// StorageMode selects how storage is provided to the pods of the job
+// +kubebuilder:validation:Enum=PerWorker;Shared;EmptyDir
+type StorageMode string
+
+const (
+	// PerWorkerStorage gives every worker its own PVC. The launcher gets no storage.
+	PerWorkerStorage StorageMode = "PerWorker"
+
+	// SharedStorage mounts a single PVC into the launcher and all workers.
+	// It requires a storage class supporting ReadWriteMany.
+	SharedStorage StorageMode = "Shared"
+
+	// EmptyDirStorage gives every pod node-local scratch space living as long as the pod
+	EmptyDirStorage StorageMode = "EmptyDir"
+)
+
+// StorageSpec configures the storage mounted into the pods of the job
+type StorageSpec struct {
+	// How the storage is provided. Defaults to PerWorker.
+	// +kubebuilder:default=PerWorker
+	// +optional
+	Mode StorageMode `json:"mode,omitempty"`
+
+	// Size of each volume. Defaults to 1Gi. For EmptyDir it is the size limit of the volume.
+	// +optional
+	Size *resource.Quantity `json:"size,omitempty"`
+
+	// Storage class of the PVCs. The cluster default is used when unset.
+	// +optional
+	StorageClassName *string `json:"storageClassName,omitempty"`
+
+	// Access mode of the PVCs. Defaults to ReadWriteOnce for PerWorker and ReadWriteMany for Shared.
+	// +optional
+	AccessMode core.PersistentVolumeAccessMode `json:"accessMode,omitempty"`
+
+	// Path the storage is mounted at in the containers. Defaults to /vmount.
+	// +optional
+	MountPath string `json:"mountPath,omitempty"`
+}
+
+// GASNetConduit is the GASNet network backend UPC++ communicates over
+// +kubebuilder:validation:Enum=udp;smp;mpi;ibv;ofi
+type GASNetConduit string
+
+const (
+	// UDPConduit communicates over UDP and works on any pod network
+	UDPConduit GASNetConduit = "udp"
+
+	// SMPConduit runs all ranks in a single pod over shared memory
+	SMPConduit GASNetConduit = "smp"
+
+	// MPIConduit communicates over MPI and is always spawned through mpirun
+	MPIConduit GASNetConduit = "mpi"
+
+	// IBVConduit communicates over InfiniBand verbs and requires RDMA devices in the pods
+	IBVConduit GASNetConduit = "ibv"
+
+	// OFIConduit communicates over libfabric and requires a matching provider in the pods
+	OFIConduit GASNetConduit = "ofi"
+)
+
+// GASNetSpawner is the mechanism used by the launcher to start the ranks on the workers
+// +kubebuilder:validation:Enum=ssh;mpi;pmi
+type GASNetSpawner string
+
+const (
+	// SSHSpawner starts the ranks over ssh using the generated job SSH keys
+	SSHSpawner GASNetSpawner = "ssh"
+
+	// MPISpawner starts the ranks through mpirun using the generated hostfile
+	MPISpawner GASNetSpawner = "mpi"
+
+	// PMISpawner starts the ranks through a PMI capable process manager
+	PMISpawner GASNetSpawner = "pmi"
+)
+
+// LaunchMode selects how the ranks of the job are started
+// +kubebuilder:validation:Enum=SSH;Direct
+type LaunchMode string
+
+const (
+	// SSHLaunch starts the ranks from the launcher, which reaches the workers over ssh or mpirun
+	SSHLaunch LaunchMode = "SSH"
+
+	// DirectLaunch starts every rank in its own pod and bootstraps them through PMI with a
+	// rendezvous on the launcher Service. No SSH keys are created and the image needs no sshd.
+	DirectLaunch LaunchMode = "Direct"
+)
+
+// NetworkSpec configures the GASNet conduit and spawner of the job
+type NetworkSpec struct {
+	// How the ranks are started. Defaults to SSH. Direct requires the mpi, ibv or ofi conduit
+	// and always uses the pmi spawner.
+	// +kubebuilder:default=SSH
+	// +optional
+	LaunchMode LaunchMode `json:"launchMode,omitempty"`
+
+	// Conduit UPC++ communicates over. Defaults to udp.
+	// +kubebuilder:default=udp
+	// +optional
+	Conduit GASNetConduit `json:"conduit,omitempty"`
+
+	// Spawner starting the ranks in the SSH launch mode. Defaults to ssh. The udp conduit supports
+	// the ssh and mpi spawners, the mpi conduit always uses mpi and the spawner is ignored for smp.
+	// +kubebuilder:default=ssh
+	// +optional
+	Spawner GASNetSpawner `json:"spawner,omitempty"`
+
+	// Additional GASNet and UPC++ environment passed to the launcher and the workers,
+	// e.g. GASNET_MAX_SEGSIZE or UPCXX_SHARED_HEAP_SIZE. Entries override the generated ones.
+	// +optional
+	Env []core.EnvVar `json:"env,omitempty"`
 }
 
+// PodGroupKind selects the flavour of PodGroup objects created for gang scheduling
+// +kubebuilder:validation:Enum=Volcano;Coscheduling
+type PodGroupKind string
+
+const (
+	// VolcanoPodGroup creates scheduling.volcano.sh PodGroups for the Volcano scheduler
+	VolcanoPodGroup PodGroupKind = "Volcano"
+
+	// CoschedulingPodGroup creates scheduling.x-k8s.io PodGroups for the scheduler-plugins coscheduling plugin
+	CoschedulingPodGroup PodGroupKind = "Coscheduling"
+)
+
+// GangSchedulingSpec configures gang scheduling of the worker pods
+type GangSchedulingSpec struct {
+	// Name of the scheduler placing the worker pods, e.g. "volcano"
+	SchedulerName string `json:"schedulerName"`
+
+	// Kind of PodGroup to create for the worker pods
+	// +kubebuilder:default=Volcano
+	// +optional
+	PodGroupKind PodGroupKind `json:"podGroupKind,omitempty"`
+
+	// Volcano queue to submit the PodGroup to
+	// +optional
+	Queue string `json:"queue,omitempty"`
+
+	// Seconds to wait for all workers to become Ready before failing the job. Defaults to 300.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	ScheduleTimeoutSeconds *int32 `json:"scheduleTimeoutSeconds,omitempty"`
+}
+
+// InputSpec locates the input graph of the job in a ConfigMap. The graph is mounted read-only
+// into the launcher and worker pods, and its path is passed in the UPCXX_INPUT variable.
+type InputSpec struct {
+	// Key of a ConfigMap in the namespace of the UPCXX holding the graph
+	ConfigMap core.ConfigMapKeySelector `json:"configMap"`
+
+	// File format of the graph, e.g. csv or mtx, passed in the UPCXX_INPUT_FORMAT variable.
+	// Detected from the contents when unset.
+	// +optional
+	Format string `json:"format,omitempty"`
+
+	// Whether the edges of an edge list are directed, passed in the UPCXX_INPUT_DIRECTED
+	// variable. Matrix Market, METIS and DIMACS files declare it themselves.
+	// +optional
+	Directed bool `json:"directed,omitempty"`
+}
+
+// VerifySpec configures checking the result of the job against a sequential reference
+// implementation. The result, and the input unless the job has one, are read from the Shared
+// storage of the job, so it is only supported with that storage mode.
+type VerifySpec struct {
+	// Path of the input edge list, relative to the mount path of the storage. Required unless
+	// the job has an input, which is verified then.
+	// +optional
+	InputPath string `json:"inputPath,omitempty"`
+
+	// Path of the result written by the algorithm, relative to the mount path of the storage
+	ResultPath string `json:"resultPath"`
+
+	// Whether the edges of the input edge list at inputPath are directed. Only bfs, sssp and
+	// pagerank run on directed graphs. The input of the job declares it itself.
+	// +optional
+	Directed bool `json:"directed,omitempty"`
+
+	// Inputs with more edges are not verified and get the Skipped verdict. Defaults to 100000.
+	// +kubebuilder:validation:Minimum=1
+	// +optional
+	MaxEdges *int64 `json:"maxEdges,omitempty"`
+}
+
+// VerificationVerdict is the outcome of the result verification
+type VerificationVerdict string
+
+const (
+	// VerificationPending means the verifier is still running
+	VerificationPending VerificationVerdict = "Pending"
+
+	// VerificationPassed means the result matches the reference implementation
+	VerificationPassed VerificationVerdict = "Passed"
+
+	// VerificationFailed means the result differs from the reference implementation
+	VerificationFailed VerificationVerdict = "Failed"
+
+	// VerificationSkipped means the input has more than maxEdges edges
+	VerificationSkipped VerificationVerdict = "Skipped"
+
+	// VerificationError means the verifier could not run, e.g. because the input is missing
+	VerificationError VerificationVerdict = "Error"
+)
+
+// VerificationStatus records the outcome of the result verification
+type VerificationStatus struct {
+	Verdict VerificationVerdict `json:"verdict"`
+
+	// Human-readable details of the verdict
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Time when the verdict was reached
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+}
+
+// UPCXXPhase is a simple, high-level summary of where the UPCXX job is in its lifecycle
+type UPCXXPhase string
+
+const (
+	// UPCXXPending means the job is accepted but does not fit into the quotas
+	UPCXXPending UPCXXPhase = "Pending"
+
+	// UPCXXQueued means the job waits in the admission queue for its turn and
+	// for enough cluster capacity to schedule all of its pods at once
+	UPCXXQueued UPCXXPhase = "Queued"
+
+	// UPCXXRunning means the job was admitted and its launcher and workers were created
+	UPCXXRunning UPCXXPhase = "Running"
+
+	// UPCXXSucceeded means the launcher Job completed successfully
+	UPCXXSucceeded UPCXXPhase = "Succeeded"
+
+	// UPCXXFailed means the launcher Job failed
+	UPCXXFailed UPCXXPhase = "Failed"
+)
+
+// Condition types of UPCXX
+const (
+	// UPCXXAdmitted tells whether the job left the admission queue and its pods were created
+	UPCXXAdmitted = "Admitted"
+
+	// UPCXXWorkersReady tells whether all worker pods are Ready to be used by the launcher
+	UPCXXWorkersReady = "WorkersReady"
+
+	// UPCXXCleanedUp tells whether the child objects of a finished job were deleted
+	UPCXXCleanedUp = "CleanedUp"
+
+	// UPCXXDegraded tells whether the operator keeps failing to reconcile the child objects of the job
+	UPCXXDegraded = "Degraded"
+)
+
 // UPCXXStatus defines the observed state of UPCXX
 type UPCXXStatus struct {
 	// INSERT ADDITIONAL STATUS FIELD - define observed state of cluster
 	// Important: Run "make" to regenerate code after modifying this file
+
+	// Current phase of the job
+	// +optional
+	Phase UPCXXPhase `json:"phase,omitempty"`
+
+	// Time when the job was admitted
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time when the job finished
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Position of the job in the admission queue, starting at 1. Zero when not queued.
+	// +optional
+	QueuePosition int32 `json:"queuePosition,omitempty"`
+
+	// Total number of ranks of the admitted job
+	// +optional
+	Ranks int32 `json:"ranks,omitempty"`
+
+	// Number of times the job was restarted after a failure
+	// +optional
+	Restarts int32 `json:"restarts,omitempty"`
+
+	// Failed attempts of the job, oldest first
+	// +optional
+	Attempts []UPCXXAttempt `json:"attempts,omitempty"`
+
+	// Outcome of the result verification, when requested by spec.verify
+	// +optional
+	Verification *VerificationStatus `json:"verification,omitempty"`
+
+	// Latest observations of the job state
+	// +optional
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+}
+
+// UPCXXAttempt records a failed attempt to run the job
+type UPCXXAttempt struct {
+	// Number of the attempt, starting at 1
+	Attempt int32 `json:"attempt"`
+
+	// Time when the attempt started
+	// +optional
+	StartTime *metav1.Time `json:"startTime,omitempty"`
+
+	// Time when the failure was detected
+	// +optional
+	CompletionTime *metav1.Time `json:"completionTime,omitempty"`
+
+	// Machine-readable cause of the failure, e.g. LauncherFailed or WorkerFailed
+	Reason string `json:"reason"`
+
+	// Human-readable details of the failure
+	// +optional
+	Message string `json:"message,omitempty"`
+
+	// Exit code of the failed container, when known
+	// +optional
+	ExitCode *int32 `json:"exitCode,omitempty"`
+}
+
+// IsWaiting tells whether the job was not admitted yet
+func (s *UPCXXStatus) IsWaiting() bool {
+	return s.Phase == "" || s.Phase == UPCXXPending || s.Phase == UPCXXQueued
+}
+
+// HoldsQuota tells whether the job counts against the quotas, which is from its admission until
+// it finishes. Both the operator and the server use this definition.
+func (s *UPCXXStatus) HoldsQuota() bool {
+	return s.Phase == UPCXXRunning
+}
+
+// IsFinished tells whether the job reached a terminal phase
+func (s *UPCXXStatus) IsFinished() bool {
+	return s.Phase == UPCXXSucceeded || s.Phase == UPCXXFailed
 }
 
 // +genclient:nonNamespaced
 //+kubebuilder:object:root=true
 //+kubebuilder:subresource:status
+//+kubebuilder:printcolumn:name="Phase",type=string,JSONPath=`.status.phase`
+//+kubebuilder:printcolumn:name="Queue",type=integer,JSONPath=`.status.queuePosition`,priority=1
+//+kubebuilder:printcolumn:name="Priority",type=integer,JSONPath=`.spec.priority`,priority=1
+//+kubebuilder:printcolumn:name="Workers",type=integer,JSONPath=`.spec.workerCount`
+//+kubebuilder:printcolumn:name="Ranks",type=integer,JSONPath=`.status.ranks`,priority=1
+//+kubebuilder:printcolumn:name="Restarts",type=integer,JSONPath=`.status.restarts`,priority=1
+//+kubebuilder:printcolumn:name="Verified",type=string,JSONPath=`.status.verification.verdict`,priority=1
+//+kubebuilder:printcolumn:name="Age",type=date,JSONPath=`.metadata.creationTimestamp`
 
 // UPCXX is the Schema for the upcxxes API
 type UPCXX struct {
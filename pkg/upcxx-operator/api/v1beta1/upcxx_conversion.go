@@ -0,0 +1,33 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	ctrl "sigs.k8s.io/controller-runtime"
+)
+
+// Hub marks v1beta1 as the version all other versions of UPCXX are converted through.
+// It is also the storage version.
+func (*UPCXX) Hub() {}
+
+// SetupWebhookWithManager registers the webhooks of UPCXX: the conversion webhook, which serves
+// the v1alpha1 version from stored v1beta1 objects and vice versa, and the validating webhook
+func (r *UPCXX) SetupWebhookWithManager(mgr ctrl.Manager) error {
+	return ctrl.NewWebhookManagedBy(mgr).
+		For(r).
+		Complete()
+}
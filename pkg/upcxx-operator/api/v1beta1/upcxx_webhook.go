@@ -0,0 +1,84 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	apiequality "k8s.io/apimachinery/pkg/api/equality"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/validation/field"
+	"sigs.k8s.io/controller-runtime/pkg/webhook"
+)
+
+//+kubebuilder:webhook:path=/validate-pgas-github-com-v1beta1-upcxx,mutating=false,failurePolicy=fail,sideEffects=None,groups=pgas.github.com,resources=upcxxes,verbs=create;update,versions=v1beta1,name=vupcxx.kb.io,admissionReviewVersions=v1
+
+var _ webhook.Validator = &UPCXX{}
+
+// ValidateCreate rejects jobs using fields the operator does not support yet
+func (r *UPCXX) ValidateCreate() error {
+	return r.validateSupported(nil)
+}
+
+// ValidateUpdate rejects updates setting fields the operator does not support yet. Fields the
+// update leaves as they were are accepted, so that jobs created before the webhook can finish.
+func (r *UPCXX) ValidateUpdate(old runtime.Object) error {
+	return r.validateSupported(old.(*UPCXX))
+}
+
+// ValidateDelete accepts every deletion
+func (r *UPCXX) ValidateDelete() error {
+	return nil
+}
+
+// validateSupported checks that the fields the controller ignores are unset, or unchanged from
+// old when it is not nil
+func (r *UPCXX) validateSupported(old *UPCXX) error {
+	var oldSpec UPCXXSpec
+	if old != nil {
+		oldSpec = old.Spec
+	}
+
+	var allErrs field.ErrorList
+	unsupported := func(path *field.Path, value, oldValue interface{}, isSet bool) {
+		if isSet && (old == nil || !apiequality.Semantic.DeepEqual(value, oldValue)) {
+			allErrs = append(allErrs, field.Forbidden(path, "not supported yet"))
+		}
+	}
+
+	specPath := field.NewPath("spec")
+	unsupported(specPath.Child("output"), r.Spec.Output, oldSpec.Output, r.Spec.Output != nil)
+
+	resources := r.Spec.Workers.Resources
+	unsupported(specPath.Child("workers", "resources"), resources, oldSpec.Workers.Resources,
+		len(resources.Limits) > 0 || len(resources.Requests) > 0)
+
+	if input := r.Spec.Input; input != nil {
+		var oldInput DataSource
+		if oldSpec.Input != nil {
+			oldInput = *oldSpec.Input
+		}
+		inputPath := specPath.Child("input")
+		unsupported(inputPath.Child("url"), input.URL, oldInput.URL, input.URL != "")
+		unsupported(inputPath.Child("persistentVolumeClaim"), input.PersistentVolumeClaim, oldInput.PersistentVolumeClaim,
+			input.PersistentVolumeClaim != nil)
+	}
+
+	if len(allErrs) == 0 {
+		return nil
+	}
+	return apierrors.NewInvalid(GroupVersion.WithKind("UPCXX").GroupKind(), r.Name, allErrs)
+}
@@ -0,0 +1,71 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package v1beta1
+
+import (
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+func TestUPCXXValidateSupported(t *testing.T) {
+	configMap := &core.ConfigMapKeySelector{
+		LocalObjectReference: core.LocalObjectReference{Name: "graphs"},
+		Key:                  "graph.txt",
+	}
+	claim := &VolumeFileSource{ClaimName: "data", Path: "out"}
+	resources := core.ResourceRequirements{
+		Requests: core.ResourceList{core.ResourceCPU: resource.MustParse("1")},
+	}
+
+	tests := map[string]struct {
+		spec  UPCXXSpec
+		valid bool
+	}{
+		"supported fields": {
+			spec:  UPCXXSpec{Algorithm: "prim", Input: &DataSource{ConfigMap: configMap}},
+			valid: true,
+		},
+		"output":                 {spec: UPCXXSpec{Output: &DataSink{PersistentVolumeClaim: claim}}},
+		"worker resources":       {spec: UPCXXSpec{Workers: WorkersSpec{Resources: resources}}},
+		"input url":              {spec: UPCXXSpec{Input: &DataSource{URL: "https://example.com/graph.txt"}}},
+		"input persistent claim": {spec: UPCXXSpec{Input: &DataSource{PersistentVolumeClaim: claim}}},
+	}
+
+	for name, tt := range tests {
+		t.Run(name, func(t *testing.T) {
+			upcxx := &UPCXX{ObjectMeta: metav1.ObjectMeta{Name: "job"}, Spec: tt.spec}
+			if err := upcxx.ValidateCreate(); (err == nil) != tt.valid {
+				t.Errorf("ValidateCreate() error = %v, want valid %v", err, tt.valid)
+			}
+
+			// Updates of jobs created before the webhook keep their unsupported fields
+			if err := upcxx.ValidateUpdate(upcxx.DeepCopy()); err != nil {
+				t.Errorf("ValidateUpdate() of an unchanged job error = %v", err)
+			}
+		})
+	}
+
+	old := &UPCXX{ObjectMeta: metav1.ObjectMeta{Name: "job"}}
+	updated := old.DeepCopy()
+	updated.Spec.Output = &DataSink{PersistentVolumeClaim: claim}
+	if err := updated.ValidateUpdate(old); err == nil {
+		t.Error("ValidateUpdate() accepted setting spec.output")
+	}
+}
@@ -0,0 +1,263 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"time"
+
+	core "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// How often jobs waiting for admission are re-checked
+const admissionRequeueInterval = 30 * time.Second
+
+//+kubebuilder:rbac:groups="",resources=nodes,verbs=get;list;watch
+//+kubebuilder:rbac:groups="",resources=pods,verbs=get;list;watch
+
+// admit decides whether a waiting job may create its pods. A job is admitted when it fits
+// into the quotas, is at the head of the admission queue and the cluster has enough free
+// capacity to schedule all of its pods at once. The outcome is recorded in the job status.
+func (r *UPCXXReconciler) admit(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) (bool, error) {
+	upcxxList := &pgasv1alpha1.UPCXXList{}
+	if err := r.Client.List(ctx, upcxxList, client.InNamespace(upcxx.Namespace)); err != nil {
+		return false, err
+	}
+
+	if reason := r.checkQuotas(upcxx, upcxxList.Items); reason != "" {
+		return false, r.setWaiting(ctx, upcxx, pgasv1alpha1.UPCXXPending, "QuotaExceeded", reason, 0)
+	}
+
+	overQuota := func(job *pgasv1alpha1.UPCXX) bool {
+		return r.checkQuotas(job, upcxxList.Items) != ""
+	}
+	position := queuePosition(upcxxList.Items, upcxx, overQuota)
+	if position > 1 {
+		message := fmt.Sprintf("waiting behind %d jobs in the admission queue", position-1)
+		return false, r.setWaiting(ctx, upcxx, pgasv1alpha1.UPCXXQueued, "Queued", message, position)
+	}
+
+	fits, err := r.fitsIntoCluster(ctx, upcxx)
+	if err != nil {
+		return false, err
+	}
+	if !fits {
+		message := fmt.Sprintf("not enough free cluster capacity to schedule all %d pods at once", *getWorkerCount(upcxx)+1)
+		return false, r.setWaiting(ctx, upcxx, pgasv1alpha1.UPCXXQueued, "InsufficientCapacity", message, position)
+	}
+
+	now := meta.Now()
+	upcxx.Status.Phase = pgasv1alpha1.UPCXXRunning
+	upcxx.Status.StartTime = &now
+	upcxx.Status.QueuePosition = 0
+	upcxx.Status.Ranks = getRankCount(upcxx)
+	apimeta.SetStatusCondition(&upcxx.Status.Conditions, meta.Condition{
+		Type:    pgasv1alpha1.UPCXXAdmitted,
+		Status:  meta.ConditionTrue,
+		Reason:  "Admitted",
+		Message: "job fits into the quotas and the cluster capacity",
+	})
+	return true, r.Client.Status().Update(ctx, upcxx)
+}
+
+// setWaiting records why the job is not admitted, skipping the update when nothing changed
+func (r *UPCXXReconciler) setWaiting(ctx context.Context, upcxx *pgasv1alpha1.UPCXX,
+	phase pgasv1alpha1.UPCXXPhase, reason, message string, position int32) error {
+	current := apimeta.FindStatusCondition(upcxx.Status.Conditions, pgasv1alpha1.UPCXXAdmitted)
+	if upcxx.Status.Phase == phase && upcxx.Status.QueuePosition == position &&
+		current != nil && current.Reason == reason && current.Message == message {
+		return nil
+	}
+
+	upcxx.Status.Phase = phase
+	upcxx.Status.QueuePosition = position
+	apimeta.SetStatusCondition(&upcxx.Status.Conditions, meta.Condition{
+		Type:    pgasv1alpha1.UPCXXAdmitted,
+		Status:  meta.ConditionFalse,
+		Reason:  reason,
+		Message: message,
+	})
+	return r.Client.Status().Update(ctx, upcxx)
+}
+
+// queuePosition returns the 1-based position of the job among the waiting jobs of the list,
+// ordered by descending priority and then by creation time. Jobs not reconciled yet are
+// waiting too, so that they are not overtaken by jobs of lower priority. Jobs for which
+// overQuota reports true are left out, as they cannot be admitted before a running job of
+// their quota scope finishes and must not hold back the jobs of other users meanwhile.
+func queuePosition(upcxxes []pgasv1alpha1.UPCXX, upcxx *pgasv1alpha1.UPCXX, overQuota func(*pgasv1alpha1.UPCXX) bool) int32 {
+	queue := []*pgasv1alpha1.UPCXX{upcxx}
+	for i := range upcxxes {
+		job := &upcxxes[i]
+		if job.Name != upcxx.Name && job.Status.IsWaiting() && !overQuota(job) {
+			queue = append(queue, job)
+		}
+	}
+
+	sort.SliceStable(queue, func(i, j int) bool {
+		if queue[i].Spec.Priority != queue[j].Spec.Priority {
+			return queue[i].Spec.Priority > queue[j].Spec.Priority
+		}
+		if !queue[i].CreationTimestamp.Equal(&queue[j].CreationTimestamp) {
+			return queue[i].CreationTimestamp.Before(&queue[j].CreationTimestamp)
+		}
+		return queue[i].Name < queue[j].Name
+	})
+
+	for i := range queue {
+		if queue[i].Name == upcxx.Name {
+			return int32(i + 1)
+		}
+	}
+
+	return 0
+}
+
+// fitsIntoCluster simulates a first-fit placement of the launcher and worker pods of the job
+// onto the free capacity of the schedulable nodes, after the pods waiting to be scheduled.
+func (r *UPCXXReconciler) fitsIntoCluster(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) (bool, error) {
+	nodeList := &core.NodeList{}
+	if err := r.Client.List(ctx, nodeList); err != nil {
+		return false, err
+	}
+
+	podList := &core.PodList{}
+	if err := r.Client.List(ctx, podList); err != nil {
+		return false, err
+	}
+
+	free := map[string]core.ResourceList{}
+	var nodes []string
+	for i := range nodeList.Items {
+		node := &nodeList.Items[i]
+		if isNodeSchedulable(node) {
+			free[node.Name] = node.Status.Allocatable.DeepCopy()
+			nodes = append(nodes, node.Name)
+		}
+	}
+
+	var unscheduled []core.ResourceList
+	for i := range podList.Items {
+		pod := &podList.Items[i]
+		if pod.Status.Phase == core.PodSucceeded || pod.Status.Phase == core.PodFailed {
+			continue
+		}
+
+		if pod.Spec.NodeName == "" {
+			unscheduled = append(unscheduled, podRequests(&pod.Spec))
+		} else if allocatable, ok := free[pod.Spec.NodeName]; ok {
+			subtractResources(allocatable, podRequests(&pod.Spec))
+		}
+	}
+
+	for _, requests := range unscheduled {
+		placePod(free, nodes, requests)
+	}
+
+	if !placePod(free, nodes, podRequests(&buildLauncherJob(upcxx, r.WaitForWorkersImage).Spec.Template.Spec)) {
+		return false, nil
+	}
+
+	workerRequests := podRequests(&buildWorkerStatefulSet(upcxx).Spec.Template.Spec)
+	for i := int32(0); i < *getWorkerCount(upcxx); i++ {
+		if !placePod(free, nodes, workerRequests) {
+			return false, nil
+		}
+	}
+
+	return true, nil
+}
+
+func isNodeSchedulable(node *core.Node) bool {
+	if node.Spec.Unschedulable {
+		return false
+	}
+
+	for _, taint := range node.Spec.Taints {
+		if taint.Effect == core.TaintEffectNoSchedule || taint.Effect == core.TaintEffectNoExecute {
+			return false
+		}
+	}
+
+	for _, condition := range node.Status.Conditions {
+		if condition.Type == core.NodeReady {
+			return condition.Status == core.ConditionTrue
+		}
+	}
+
+	return false
+}
+
+// podRequests sums up the resource requests of the pod, counting the pod itself too
+func podRequests(spec *core.PodSpec) core.ResourceList {
+	requests := core.ResourceList{core.ResourcePods: *resource.NewQuantity(1, resource.DecimalSI)}
+	for _, container := range spec.Containers {
+		for name, quantity := range container.Resources.Requests {
+			total := requests[name]
+			total.Add(quantity)
+			requests[name] = total
+		}
+	}
+
+	// Init containers run one by one, so the pod needs at least the largest of them
+	for _, container := range spec.InitContainers {
+		for name, quantity := range container.Resources.Requests {
+			if total := requests[name]; quantity.Cmp(total) > 0 {
+				requests[name] = quantity.DeepCopy()
+			}
+		}
+	}
+
+	return requests
+}
+
+func subtractResources(from, requests core.ResourceList) {
+	for name, quantity := range requests {
+		if available, ok := from[name]; ok {
+			available.Sub(quantity)
+			from[name] = available
+		}
+	}
+}
+
+// placePod reserves the requests on the first node with enough free resources
+func placePod(free map[string]core.ResourceList, nodes []string, requests core.ResourceList) bool {
+	for _, node := range nodes {
+		fits := true
+		for name, quantity := range requests {
+			if available := free[node][name]; available.Cmp(quantity) < 0 {
+				fits = false
+				break
+			}
+		}
+
+		if fits {
+			subtractResources(free[node], requests)
+			return true
+		}
+	}
+
+	return false
+}
@@ -0,0 +1,73 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"testing"
+	"time"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+func TestQueuePosition(t *testing.T) {
+	created := time.Date(2021, 11, 1, 0, 0, 0, 0, time.UTC)
+	newJob := func(name, owner string, priority int32, age time.Duration, phase pgasv1alpha1.UPCXXPhase) pgasv1alpha1.UPCXX {
+		return pgasv1alpha1.UPCXX{
+			ObjectMeta: meta.ObjectMeta{
+				Name:              name,
+				Labels:            map[string]string{pgasv1alpha1.OwnerLabel: owner},
+				CreationTimestamp: meta.NewTime(created.Add(-age)),
+			},
+			Spec:   pgasv1alpha1.UPCXXSpec{Priority: priority},
+			Status: pgasv1alpha1.UPCXXStatus{Phase: phase},
+		}
+	}
+
+	jobs := []pgasv1alpha1.UPCXX{
+		newJob("running", "alice", 100, 4*time.Hour, pgasv1alpha1.UPCXXRunning),
+		newJob("finished", "bob", 100, 4*time.Hour, pgasv1alpha1.UPCXXSucceeded),
+		newJob("queued-old", "bob", 1, 3*time.Hour, pgasv1alpha1.UPCXXQueued),
+		newJob("queued-new", "bob", 1, 2*time.Hour, pgasv1alpha1.UPCXXQueued),
+		newJob("over-quota", "alice", 5, time.Hour, pgasv1alpha1.UPCXXPending),
+		newJob("unreconciled", "bob", 10, 0, ""),
+	}
+
+	r := &UPCXXReconciler{UserQuota: Quota{MaxComputations: 1}}
+	overQuota := func(job *pgasv1alpha1.UPCXX) bool {
+		return r.checkQuotas(job, jobs) != ""
+	}
+
+	// The job of alice waits for her running job and does not hold back the jobs of bob
+	tests := map[string]int32{
+		"unreconciled": 1,
+		"over-quota":   2,
+		"queued-old":   2,
+		"queued-new":   3,
+	}
+	for name, want := range tests {
+		for i := range jobs {
+			if jobs[i].Name != name {
+				continue
+			}
+			if got := queuePosition(jobs, &jobs[i], overQuota); got != want {
+				t.Errorf("queuePosition(%s) = %d, want %d", name, got, want)
+			}
+		}
+	}
+}
@@ -0,0 +1,67 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"sort"
+
+	core "k8s.io/api/core/v1"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// validateAlgorithm checks that the algorithm of the job is registered and accepts its parameters
+func validateAlgorithm(upcxx *pgasv1alpha1.UPCXX) error {
+	spec, ok := glconstants.LookupAlgorithm(upcxx.Spec.Algorithm)
+	if !ok {
+		return fmt.Errorf("unknown algorithm %s", upcxx.Spec.Algorithm)
+	}
+
+	_, err := spec.ValidateParameters(upcxx.Spec.Parameters)
+	return err
+}
+
+// buildAlgorithmArgs passes the parameters of the job, with defaults filled in, as --name=value
+// arguments sorted by name. No child is built before validateAlgorithm accepted the parameters,
+// so they are valid here.
+func buildAlgorithmArgs(spec glconstants.AlgorithmSpec, upcxx *pgasv1alpha1.UPCXX) []string {
+	params, _ := spec.ValidateParameters(upcxx.Spec.Parameters)
+
+	var args []string
+	for name, value := range params {
+		args = append(args, fmt.Sprintf("--%s=%s", name, value))
+	}
+	sort.Strings(args)
+	return args
+}
+
+// setupAlgorithmOnPod runs the entrypoint of the algorithm in the pods starting the ranks: the
+// launcher, and in the Direct launch mode also the workers. Other workers keep the default
+// command of the image, which waits for the ranks spawned by the launcher.
+func setupAlgorithmOnPod(podSpec *core.PodSpec, upcxx *pgasv1alpha1.UPCXX, isWorker bool) {
+	spec, ok := glconstants.LookupAlgorithm(upcxx.Spec.Algorithm)
+	if !ok || (isWorker && !isDirectLaunch(upcxx)) {
+		return
+	}
+
+	mainContainer := &podSpec.Containers[0]
+	mainContainer.Command = spec.Entrypoint
+	mainContainer.Args = buildAlgorithmArgs(spec, upcxx)
+	mainContainer.Env = append([]core.EnvVar{{Name: "UPCXX_ALGORITHM", Value: string(spec.Name)}}, mainContainer.Env...)
+}
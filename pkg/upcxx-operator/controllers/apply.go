@@ -0,0 +1,66 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// Field manager of the operator in server-side apply. It must stay stable, otherwise the
+// fields set by an older version of the operator are not released by a newer one.
+const fieldManager = "upcxx-operator"
+
+// applyChild server-side applies a child object built by one of the builders. The operator
+// owns exactly the fields set by the builder, so fields added by users or other controllers,
+// e.g. annotations, are left alone. On return obj holds the object as stored in the cluster.
+// An object which is being deleted is left alone and reported as terminating, it is recreated
+// by a later reconcile once it is gone.
+func (r *UPCXXReconciler) applyChild(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, obj client.Object, kind string) (bool, error) {
+	gvk, err := apiutil.GVKForObject(obj, r.Scheme)
+	if err != nil {
+		return false, err
+	}
+	obj.GetObjectKind().SetGroupVersionKind(gvk)
+
+	existing := obj.DeepCopyObject().(client.Object)
+	err = r.Client.Get(ctx, client.ObjectKeyFromObject(obj), existing)
+	if client.IgnoreNotFound(err) != nil {
+		return false, err
+	}
+	created := apierrors.IsNotFound(err)
+
+	if !created && existing.GetDeletionTimestamp() != nil {
+		return true, nil
+	}
+
+	if err := r.Client.Patch(ctx, obj, client.Apply, client.FieldOwner(fieldManager), client.ForceOwnership); err != nil {
+		return false, err
+	}
+
+	if created {
+		r.Recorder.Eventf(upcxx, core.EventTypeNormal, "Created "+kind, obj.GetName())
+	}
+
+	return false, nil
+}
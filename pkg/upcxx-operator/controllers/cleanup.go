@@ -0,0 +1,143 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// markFinished moves the job into a terminal phase and records its completion time
+func markFinished(upcxx *pgasv1alpha1.UPCXX, phase pgasv1alpha1.UPCXXPhase, completionTime *meta.Time) {
+	if completionTime == nil {
+		now := meta.Now()
+		completionTime = &now
+	}
+
+	upcxx.Status.Phase = phase
+	upcxx.Status.CompletionTime = completionTime
+}
+
+// reconcileFinished releases the resources of a finished job: the workers are scaled
+// to zero right away and the child objects are deleted once ttlSecondsAfterFinished passed.
+func (r *UPCXXReconciler) reconcileFinished(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) (ctrl.Result, error) {
+	logger := r.Log.WithValues("UPCXX", client.ObjectKeyFromObject(upcxx))
+
+	if apimeta.IsStatusConditionTrue(upcxx.Status.Conditions, pgasv1alpha1.UPCXXCleanedUp) {
+		return ctrl.Result{}, nil
+	}
+
+	statefulSet := &apps.StatefulSet{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: upcxx.Namespace, Name: buildWorkerPodName(upcxx)}, statefulSet)
+	if err != nil && !apierrors.IsNotFound(err) {
+		return ctrl.Result{}, err
+	}
+
+	if err == nil && (statefulSet.Spec.Replicas == nil || *statefulSet.Spec.Replicas != 0) {
+		// Scale down through server-side apply of the whole StatefulSet, so that the
+		// operator keeps owning the same fields as when the workers were created
+		scaled := buildWorkerStatefulSet(upcxx)
+		scaled.Spec.Replicas = int32ToPtr(0)
+		if _, err := r.applyChild(ctx, upcxx, scaled, "StatefulSet"); err != nil {
+			logger.Error(err, "Unable to scale down workers of finished job")
+			return ctrl.Result{}, err
+		}
+
+		r.Recorder.Eventf(upcxx, core.EventTypeNormal, "Scaled down workers of finished job", statefulSet.Name)
+	}
+
+	verified, err := r.reconcileVerification(ctx, upcxx)
+	if err != nil {
+		logger.Error(err, "Unable to verify the result of finished job")
+		return ctrl.Result{}, err
+	}
+	if !verified {
+		// The verifier Job is owned by the UPCXX, its completion triggers the next reconcile
+		return ctrl.Result{}, nil
+	}
+
+	ttl := upcxx.Spec.TTLSecondsAfterFinished
+	if ttl == nil {
+		return ctrl.Result{}, nil
+	}
+
+	completionTime := upcxx.CreationTimestamp.Time
+	if upcxx.Status.CompletionTime != nil {
+		completionTime = upcxx.Status.CompletionTime.Time
+	}
+
+	if remaining := time.Until(completionTime.Add(time.Duration(*ttl) * time.Second)); remaining > 0 {
+		return ctrl.Result{RequeueAfter: remaining}, nil
+	}
+
+	if err := r.deleteChildren(ctx, upcxx); err != nil {
+		logger.Error(err, "Unable to delete child objects of finished job")
+		return ctrl.Result{}, err
+	}
+
+	r.Recorder.Eventf(upcxx, core.EventTypeNormal, "Deleted child objects of finished job", upcxx.Name)
+
+	apimeta.SetStatusCondition(&upcxx.Status.Conditions, meta.Condition{
+		Type:    pgasv1alpha1.UPCXXCleanedUp,
+		Status:  meta.ConditionTrue,
+		Reason:  "TTLExpired",
+		Message: "child objects were deleted after ttlSecondsAfterFinished",
+	})
+	return ctrl.Result{}, r.Client.Status().Update(ctx, upcxx)
+}
+
+// deleteChildren deletes every object the operator created for the job
+func (r *UPCXXReconciler) deleteChildren(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) error {
+	children := []client.Object{
+		&batch.Job{ObjectMeta: meta.ObjectMeta{Namespace: upcxx.Namespace, Name: buildLauncherJobName(upcxx)}},
+		&batch.Job{ObjectMeta: meta.ObjectMeta{Namespace: upcxx.Namespace, Name: buildVerifierJobName(upcxx)}},
+		&apps.StatefulSet{ObjectMeta: meta.ObjectMeta{Namespace: upcxx.Namespace, Name: buildWorkerPodName(upcxx)}},
+		&core.Service{ObjectMeta: meta.ObjectMeta{Namespace: upcxx.Namespace, Name: buildLauncherJobName(upcxx)}},
+		&core.Service{ObjectMeta: meta.ObjectMeta{Namespace: upcxx.Namespace, Name: buildWorkerPodName(upcxx)}},
+		&core.ConfigMap{ObjectMeta: meta.ObjectMeta{Namespace: upcxx.Namespace, Name: buildSSHAuthSecretName(upcxx)}},
+		&core.ConfigMap{ObjectMeta: meta.ObjectMeta{Namespace: upcxx.Namespace, Name: buildHostfileName(upcxx)}},
+	}
+
+	if upcxx.Spec.GangScheduling != nil {
+		podGroup := &unstructured.Unstructured{}
+		podGroup.SetGroupVersionKind(buildPodGroup(upcxx).GroupVersionKind())
+		podGroup.SetNamespace(upcxx.Namespace)
+		podGroup.SetName(buildPodGroupName(upcxx))
+		children = append(children, podGroup)
+	}
+
+	for _, child := range children {
+		err := r.Client.Delete(ctx, child, client.PropagationPolicy(meta.DeletePropagationBackground))
+		if client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
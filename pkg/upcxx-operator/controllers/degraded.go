@@ -0,0 +1,67 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// degraded reports a failure to reconcile the children of the job through a warning Event and
+// the Degraded condition, which stays set until a reconcile gets through. It returns the error
+// so that the request is retried with backoff.
+func (r *UPCXXReconciler) degraded(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, reason string, err error) error {
+	r.Recorder.Eventf(upcxx, core.EventTypeWarning, reason, "%v", err)
+
+	current := apimeta.FindStatusCondition(upcxx.Status.Conditions, pgasv1alpha1.UPCXXDegraded)
+	if current != nil && current.Status == meta.ConditionTrue && current.Reason == reason && current.Message == err.Error() {
+		return err
+	}
+
+	apimeta.SetStatusCondition(&upcxx.Status.Conditions, meta.Condition{
+		Type:    pgasv1alpha1.UPCXXDegraded,
+		Status:  meta.ConditionTrue,
+		Reason:  reason,
+		Message: err.Error(),
+	})
+	if updateErr := r.Client.Status().Update(ctx, upcxx); updateErr != nil {
+		r.Log.WithValues("UPCXX", client.ObjectKeyFromObject(upcxx)).Error(updateErr, "Unable to set Degraded condition")
+	}
+
+	return err
+}
+
+// clearDegraded resets the Degraded condition once the children of the job were reconciled
+func (r *UPCXXReconciler) clearDegraded(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) error {
+	if !apimeta.IsStatusConditionTrue(upcxx.Status.Conditions, pgasv1alpha1.UPCXXDegraded) {
+		return nil
+	}
+
+	apimeta.SetStatusCondition(&upcxx.Status.Conditions, meta.Condition{
+		Type:    pgasv1alpha1.UPCXXDegraded,
+		Status:  meta.ConditionFalse,
+		Reason:  "Reconciled",
+		Message: "all child objects of the job were reconciled",
+	})
+	return r.Client.Status().Update(ctx, upcxx)
+}
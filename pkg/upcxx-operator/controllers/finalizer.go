@@ -0,0 +1,78 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// Finalizer deleting the data of a UPCXX which is not garbage collected through OwnerReferences
+const cleanupFinalizer = "pgas.github.com/cleanup"
+
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;delete;deletecollection
+//+kubebuilder:rbac:groups="",resources=configmaps,verbs=deletecollection
+
+// finalize deletes the worker PVCs and result artifacts of a deleted UPCXX, unless it asks
+// to retain them, and then releases the UPCXX by removing the finalizer.
+func (r *UPCXXReconciler) finalize(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) (ctrl.Result, error) {
+	logger := r.Log.WithValues("UPCXX", client.ObjectKeyFromObject(upcxx))
+
+	if !controllerutil.ContainsFinalizer(upcxx, cleanupFinalizer) {
+		return ctrl.Result{}, nil
+	}
+
+	if upcxx.Spec.RetainData {
+		logger.Info("Retaining worker PVCs and result artifacts of deleted UPCXX")
+	} else if err := r.deleteData(ctx, upcxx); err != nil {
+		logger.Error(err, "Unable to delete data of deleted UPCXX")
+		return ctrl.Result{}, err
+	}
+
+	controllerutil.RemoveFinalizer(upcxx, cleanupFinalizer)
+	if err := r.Client.Update(ctx, upcxx); err != nil {
+		return ctrl.Result{}, client.IgnoreNotFound(err)
+	}
+
+	return ctrl.Result{}, nil
+}
+
+// deleteData deletes the PVCs and ConfigMaps labelled with the UPCXX. The PVCs
+// created from the worker volumeClaimTemplates inherit the label from the template.
+func (r *UPCXXReconciler) deleteData(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) error {
+	selector := []client.DeleteAllOfOption{
+		client.InNamespace(upcxx.Namespace),
+		client.MatchingLabels{pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name)},
+	}
+
+	if err := r.Client.DeleteAllOf(ctx, &core.PersistentVolumeClaim{}, selector...); err != nil {
+		return err
+	}
+
+	if err := r.Client.DeleteAllOf(ctx, &core.ConfigMap{}, selector...); err != nil {
+		return err
+	}
+
+	r.Recorder.Eventf(upcxx, core.EventTypeNormal, "Deleted worker PVCs and result artifacts", upcxx.Name)
+	return nil
+}
@@ -0,0 +1,116 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"time"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/apis/meta/v1/unstructured"
+	"k8s.io/apimachinery/pkg/runtime/schema"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+const (
+	defaultScheduleTimeoutSeconds = 300
+
+	// Pod annotation and label binding pods to their PodGroup
+	volcanoGroupNameAnnotation = "scheduling.k8s.io/group-name"
+	coschedulingPodGroupLabel  = "scheduling.x-k8s.io/pod-group"
+)
+
+var (
+	volcanoPodGroupGVK      = schema.GroupVersionKind{Group: "scheduling.volcano.sh", Version: "v1beta1", Kind: "PodGroup"}
+	coschedulingPodGroupGVK = schema.GroupVersionKind{Group: "scheduling.x-k8s.io", Version: "v1alpha1", Kind: "PodGroup"}
+)
+
+//+kubebuilder:rbac:groups=scheduling.volcano.sh;scheduling.x-k8s.io,resources=podgroups,verbs=get;list;watch;create;update;patch;delete
+
+func buildPodGroupName(upcxx *pgasv1alpha1.UPCXX) string {
+	return buildWorkerPodName(upcxx)
+}
+
+// getScheduleTimeout returns how long the workers may take to become Ready, which only gang
+// scheduling lets the user change
+func getScheduleTimeout(upcxx *pgasv1alpha1.UPCXX) time.Duration {
+	if gang := upcxx.Spec.GangScheduling; gang != nil && gang.ScheduleTimeoutSeconds != nil {
+		return time.Duration(*gang.ScheduleTimeoutSeconds) * time.Second
+	}
+	return defaultScheduleTimeoutSeconds * time.Second
+}
+
+// buildPodGroup builds the PodGroup requiring all worker pods to be scheduled together.
+// The launcher is not a member since it is only created once the workers are Ready.
+func buildPodGroup(upcxx *pgasv1alpha1.UPCXX) *unstructured.Unstructured {
+	gang := upcxx.Spec.GangScheduling
+	minMember := int64(*getWorkerCount(upcxx))
+
+	minResources := map[string]interface{}{}
+	for name, quantity := range podRequests(&buildWorkerStatefulSet(upcxx).Spec.Template.Spec) {
+		if name == core.ResourcePods {
+			continue
+		}
+		total := quantity.DeepCopy()
+		for i := int64(1); i < minMember; i++ {
+			total.Add(quantity)
+		}
+		minResources[string(name)] = total.String()
+	}
+
+	spec := map[string]interface{}{
+		"minMember":    minMember,
+		"minResources": minResources,
+	}
+
+	podGroup := &unstructured.Unstructured{}
+	if gang.PodGroupKind == pgasv1alpha1.CoschedulingPodGroup {
+		podGroup.SetGroupVersionKind(coschedulingPodGroupGVK)
+		spec["scheduleTimeoutSeconds"] = int64(getScheduleTimeout(upcxx) / time.Second)
+	} else {
+		podGroup.SetGroupVersionKind(volcanoPodGroupGVK)
+		if gang.Queue != "" {
+			spec["queue"] = gang.Queue
+		}
+	}
+
+	podGroup.SetName(buildPodGroupName(upcxx))
+	podGroup.SetNamespace(upcxx.Namespace)
+	podGroup.SetOwnerReferences([]meta.OwnerReference{
+		*meta.NewControllerRef(upcxx, pgasv1alpha1.GroupVersion.WithKind("UPCXX")),
+	})
+	podGroup.Object["spec"] = spec
+
+	return podGroup
+}
+
+// setupGangSchedulingOnPod hands the pod over to the gang scheduler as a member of the job PodGroup
+func setupGangSchedulingOnPod(template *core.PodTemplateSpec, upcxx *pgasv1alpha1.UPCXX) {
+	gang := upcxx.Spec.GangScheduling
+	template.Spec.SchedulerName = gang.SchedulerName
+
+	if gang.PodGroupKind == pgasv1alpha1.CoschedulingPodGroup {
+		template.Labels[coschedulingPodGroupLabel] = buildPodGroupName(upcxx)
+		return
+	}
+
+	if template.Annotations == nil {
+		template.Annotations = map[string]string{}
+	}
+	template.Annotations[volcanoGroupNameAnnotation] = buildPodGroupName(upcxx)
+}
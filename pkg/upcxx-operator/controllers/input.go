@@ -0,0 +1,132 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path"
+
+	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	glgraph "github.com/lnikon/glfs-pkg/pkg/graph"
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+const (
+	// Name of the input volume in the pods
+	inputVolume = "input"
+
+	inputMountPath = "/input"
+)
+
+// validateInput checks that the input ConfigMap is named and its format is known
+func validateInput(upcxx *pgasv1alpha1.UPCXX) error {
+	input := upcxx.Spec.Input
+	if input == nil {
+		return nil
+	}
+
+	if input.ConfigMap.Name == "" || input.ConfigMap.Key == "" {
+		return errors.New("input configMap needs a name and a key")
+	}
+	if input.Format != "" {
+		if _, err := glgraph.ParseFormat(input.Format); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// checkInputExists returns an error describing the missing input of the job when its ConfigMap
+// or key does not exist. Unlike validateInput it reads the cluster, so Render does not run it.
+func (r *UPCXXReconciler) checkInputExists(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) (missing error, err error) {
+	input := upcxx.Spec.Input
+	if input == nil {
+		return nil, nil
+	}
+
+	configMap := &core.ConfigMap{}
+	err = r.Client.Get(ctx, client.ObjectKey{Namespace: upcxx.Namespace, Name: input.ConfigMap.Name}, configMap)
+	if apierrors.IsNotFound(err) {
+		return fmt.Errorf("input configMap %s does not exist", input.ConfigMap.Name), nil
+	} else if err != nil {
+		return nil, err
+	}
+
+	_, inData := configMap.Data[input.ConfigMap.Key]
+	_, inBinaryData := configMap.BinaryData[input.ConfigMap.Key]
+	if !inData && !inBinaryData {
+		return fmt.Errorf("input configMap %s has no key %s", input.ConfigMap.Name, input.ConfigMap.Key), nil
+	}
+
+	return nil, nil
+}
+
+// setupInputOnPod mounts the key of the input ConfigMap read-only into the main container and
+// passes its path, format and whether it is directed to the algorithm
+func setupInputOnPod(podSpec *core.PodSpec, upcxx *pgasv1alpha1.UPCXX) {
+	input := upcxx.Spec.Input
+	if input == nil {
+		return
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes, core.Volume{
+		Name: inputVolume,
+		VolumeSource: core.VolumeSource{
+			ConfigMap: &core.ConfigMapVolumeSource{
+				LocalObjectReference: input.ConfigMap.LocalObjectReference,
+				Items: []core.KeyToPath{
+					{Key: input.ConfigMap.Key, Path: input.ConfigMap.Key},
+				},
+			},
+		},
+	})
+
+	mainContainer := &podSpec.Containers[0]
+	mainContainer.VolumeMounts = append(mainContainer.VolumeMounts,
+		core.VolumeMount{
+			Name:      inputVolume,
+			MountPath: inputMountPath,
+			ReadOnly:  true,
+		})
+	mainContainer.Env = append(mainContainer.Env, core.EnvVar{
+		Name:  "UPCXX_INPUT",
+		Value: buildInputPath(upcxx),
+	})
+	if input.Format != "" {
+		mainContainer.Env = append(mainContainer.Env, core.EnvVar{
+			Name:  "UPCXX_INPUT_FORMAT",
+			Value: input.Format,
+		})
+	}
+	if input.Directed {
+		mainContainer.Env = append(mainContainer.Env, core.EnvVar{
+			Name:  "UPCXX_INPUT_DIRECTED",
+			Value: "true",
+		})
+	}
+}
+
+// buildInputPath returns where setupInputOnPod mounts the input of the job
+func buildInputPath(upcxx *pgasv1alpha1.UPCXX) string {
+	return path.Join(inputMountPath, upcxx.Spec.Input.ConfigMap.Key)
+}
@@ -0,0 +1,109 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strconv"
+
+	core "k8s.io/api/core/v1"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+const (
+	// Port of the PMI rendezvous served by the launcher in the Direct launch mode
+	rendezvousPortName = "pmi"
+	rendezvousPort     = 7000
+)
+
+// isDirectLaunch tells whether every rank is started in its own pod without SSH
+func isDirectLaunch(upcxx *pgasv1alpha1.UPCXX) bool {
+	return getNetwork(upcxx).LaunchMode == pgasv1alpha1.DirectLaunch
+}
+
+func buildRendezvousAddress(upcxx *pgasv1alpha1.UPCXX) string {
+	return fmt.Sprintf("%s:%d", buildLauncherJobName(upcxx), rendezvousPort)
+}
+
+// createDirectLaunchEnvVars describes the place of the ranks of the pod in the job. The launcher
+// serves the rendezvous and runs the first ranks when it is a worker. The first rank of a worker
+// is ordinal*ranksPerPod+UPCXX_RANK_OFFSET, where the ordinal is the suffix of UPCXX_POD_NAME,
+// since the pod template is shared by all workers of the StatefulSet.
+func createDirectLaunchEnvVars(upcxx *pgasv1alpha1.UPCXX, isWorker bool) []core.EnvVar {
+	envVars := []core.EnvVar{
+		{
+			Name:  "PMI_SIZE",
+			Value: strconv.Itoa(int(getRankCount(upcxx))),
+		},
+		{
+			Name:  "PMI_PORT",
+			Value: buildRendezvousAddress(upcxx),
+		},
+	}
+
+	if !isWorker {
+		if launcherIsWorker(upcxx) {
+			envVars = append(envVars, core.EnvVar{Name: "PMI_RANK", Value: "0"})
+		}
+		return envVars
+	}
+
+	rankOffset := int32(0)
+	if launcherIsWorker(upcxx) {
+		rankOffset = getRanksPerPod(upcxx)
+	}
+
+	return append(envVars,
+		core.EnvVar{
+			Name: "UPCXX_POD_NAME",
+			ValueFrom: &core.EnvVarSource{
+				FieldRef: &core.ObjectFieldSelector{FieldPath: "metadata.name"},
+			},
+		},
+		core.EnvVar{
+			Name:  "UPCXX_RANK_OFFSET",
+			Value: strconv.Itoa(int(rankOffset)),
+		})
+}
+
+// setupLaunchOnPod prepares the pod for the launch mode of the job: either the SSH keys are
+// mounted, or the rank information is passed and the launcher exposes the rendezvous port.
+func setupLaunchOnPod(podSpec *core.PodSpec, upcxx *pgasv1alpha1.UPCXX, isWorker bool) {
+	mainContainer := &podSpec.Containers[0]
+	if !isDirectLaunch(upcxx) {
+		setupSSHOnPod(podSpec, upcxx)
+		if !isWorker {
+			// The spawned ranks connect back to the launcher, which must be given as an address
+			mainContainer.Env = append([]core.EnvVar{{
+				Name: "GASNET_MASTERIP",
+				ValueFrom: &core.EnvVarSource{
+					FieldRef: &core.ObjectFieldSelector{FieldPath: "status.podIP"},
+				},
+			}}, mainContainer.Env...)
+		}
+		return
+	}
+
+	mainContainer.Env = append(createDirectLaunchEnvVars(upcxx, isWorker), mainContainer.Env...)
+	if !isWorker {
+		mainContainer.Ports = append(mainContainer.Ports, core.ContainerPort{
+			Name:          rendezvousPortName,
+			ContainerPort: rendezvousPort,
+		})
+	}
+}
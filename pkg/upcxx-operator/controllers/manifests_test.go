@@ -0,0 +1,180 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"flag"
+	"os"
+	"path/filepath"
+	"testing"
+
+	core "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// Regenerate the golden files with: go test ./controllers -run TestManifests -update
+var update = flag.Bool("update", false, "update the golden files of the generated manifests")
+
+func newSampleUPCXX(name string, spec pgasv1alpha1.UPCXXSpec) *pgasv1alpha1.UPCXX {
+	if spec.WorkerCount == 0 {
+		spec.WorkerCount = 2
+	}
+	if spec.Algorithm == "" {
+		spec.Algorithm = glconstants.Kruskal
+	}
+
+	return &pgasv1alpha1.UPCXX{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+			UID:       "00000000-0000-0000-0000-000000000000",
+		},
+		Spec: spec,
+	}
+}
+
+func TestManifests(t *testing.T) {
+	storageClassName := "nfs"
+	size := resource.MustParse("10Gi")
+	timeoutSeconds := int32(120)
+	launcherIsWorker := false
+
+	tests := []struct {
+		name  string
+		upcxx *pgasv1alpha1.UPCXX
+	}{
+		{
+			name:  "default",
+			upcxx: newSampleUPCXX("default", pgasv1alpha1.UPCXXSpec{}),
+		},
+		{
+			name: "ranks-per-pod",
+			upcxx: newSampleUPCXX("ranks-per-pod", pgasv1alpha1.UPCXXSpec{
+				WorkerCount:      3,
+				RanksPerPod:      int32ToPtr(4),
+				LauncherIsWorker: &launcherIsWorker,
+			}),
+		},
+		{
+			name: "direct-launch",
+			upcxx: newSampleUPCXX("direct-launch", pgasv1alpha1.UPCXXSpec{
+				Network: &pgasv1alpha1.NetworkSpec{
+					LaunchMode: pgasv1alpha1.DirectLaunch,
+					Conduit:    pgasv1alpha1.IBVConduit,
+					Spawner:    pgasv1alpha1.PMISpawner,
+				},
+			}),
+		},
+		{
+			name: "prim-direct-launch",
+			upcxx: newSampleUPCXX("prim-direct-launch", pgasv1alpha1.UPCXXSpec{
+				Algorithm:  glconstants.Prim,
+				Parameters: map[string]string{"root": "3"},
+				Network: &pgasv1alpha1.NetworkSpec{
+					LaunchMode: pgasv1alpha1.DirectLaunch,
+					Conduit:    pgasv1alpha1.OFIConduit,
+				},
+			}),
+		},
+		{
+			name: "pagerank",
+			upcxx: newSampleUPCXX("pagerank", pgasv1alpha1.UPCXXSpec{
+				Algorithm:  glconstants.PageRank,
+				Parameters: map[string]string{"damping": "0.9", "iterations": "50"},
+			}),
+		},
+		{
+			name: "configmap-input",
+			upcxx: newSampleUPCXX("configmap-input", pgasv1alpha1.UPCXXSpec{
+				Algorithm: glconstants.BFS,
+				Input: &pgasv1alpha1.InputSpec{
+					ConfigMap: core.ConfigMapKeySelector{
+						LocalObjectReference: core.LocalObjectReference{Name: "configmap-input-input"},
+						Key:                  "graph.txt",
+					},
+					Format:   "edgelist",
+					Directed: true,
+				},
+			}),
+		},
+		{
+			name: "mpi-spawner",
+			upcxx: newSampleUPCXX("mpi-spawner", pgasv1alpha1.UPCXXSpec{
+				WorkerCount: 4,
+				RanksPerPod: int32ToPtr(2),
+				Network: &pgasv1alpha1.NetworkSpec{
+					Conduit: pgasv1alpha1.MPIConduit,
+					Spawner: pgasv1alpha1.MPISpawner,
+				},
+			}),
+		},
+		{
+			name: "shared-storage",
+			upcxx: newSampleUPCXX("shared-storage", pgasv1alpha1.UPCXXSpec{
+				Storage: &pgasv1alpha1.StorageSpec{
+					Mode:             pgasv1alpha1.SharedStorage,
+					Size:             &size,
+					StorageClassName: &storageClassName,
+				},
+			}),
+		},
+		{
+			name: "gang-scheduling",
+			upcxx: newSampleUPCXX("gang-scheduling", pgasv1alpha1.UPCXXSpec{
+				ActiveDeadlineSeconds:   int64ToPtr(3600),
+				TTLSecondsAfterFinished: int32ToPtr(600),
+				GangScheduling: &pgasv1alpha1.GangSchedulingSpec{
+					SchedulerName:          "volcano",
+					Queue:                  "research",
+					ScheduleTimeoutSeconds: &timeoutSeconds,
+				},
+			}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := RenderYAML(tt.upcxx)
+			if err != nil {
+				t.Fatalf("unable to render manifests: %v", err)
+			}
+
+			golden := filepath.Join("testdata", "golden", tt.name+".yaml")
+			if *update {
+				if err := os.MkdirAll(filepath.Dir(golden), 0755); err != nil {
+					t.Fatal(err)
+				}
+				if err := os.WriteFile(golden, got, 0644); err != nil {
+					t.Fatal(err)
+				}
+			}
+
+			want, err := os.ReadFile(golden)
+			if err != nil {
+				t.Fatalf("unable to read golden file, run with -update to create it: %v", err)
+			}
+			if !bytes.Equal(got, want) {
+				t.Errorf("manifests differ from %s, run with -update to regenerate them:\n%s", golden, got)
+			}
+		})
+	}
+}
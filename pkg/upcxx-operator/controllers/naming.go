@@ -0,0 +1,57 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"strings"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+const (
+	// Longest name of a child object. Child names end up in DNS labels, which are limited to
+	// 63 characters, and the StatefulSet controller appends an 11 character revision hash to
+	// the worker StatefulSet name in the controller-revision-hash label of the workers.
+	maxChildNameLength = 52
+
+	// Length of the hash telling apart the truncated names of different jobs
+	childNameHashLength = 8
+)
+
+// buildBaseName returns the name all child objects of the job are derived from
+func buildBaseName(upcxx *pgasv1alpha1.UPCXX) string {
+	return buildChildName(upcxx, "")
+}
+
+// buildChildName derives the name of a child object from the name of the job and the suffix
+// of the child. Names too long for a DNS label are truncated, and a hash of the full name of
+// the job is appended so that jobs sharing a long prefix do not collide.
+func buildChildName(upcxx *pgasv1alpha1.UPCXX, suffix string) string {
+	name := upcxx.Name + suffix
+	if len(name) <= maxChildNameLength {
+		return name
+	}
+
+	sum := sha256.Sum256([]byte(upcxx.Name))
+	hash := hex.EncodeToString(sum[:])[:childNameHashLength]
+
+	prefix := upcxx.Name[:maxChildNameLength-len(suffix)-childNameHashLength-1]
+	prefix = strings.TrimRight(prefix, "-.")
+	return prefix + "-" + hash + suffix
+}
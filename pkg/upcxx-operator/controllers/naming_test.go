@@ -0,0 +1,98 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"strings"
+	"testing"
+
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/validation"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+func TestBuildChildName(t *testing.T) {
+	long := strings.Repeat("a", 60)
+
+	tests := []struct {
+		name   string
+		job    string
+		suffix string
+		want   string
+	}{
+		{name: "short", job: "job", suffix: workerSuffix, want: "job-worker"},
+		{name: "fits exactly", job: strings.Repeat("a", 45), suffix: workerSuffix, want: strings.Repeat("a", 45) + workerSuffix},
+		{name: "truncated", job: long, suffix: workerSuffix, want: strings.Repeat("a", 36) + "-11ee3912" + workerSuffix},
+		{name: "truncated at separator", job: strings.Repeat("a", 35) + "-" + long, suffix: workerSuffix, want: strings.Repeat("a", 35) + "-23563117" + workerSuffix},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			upcxx := &pgasv1alpha1.UPCXX{ObjectMeta: meta.ObjectMeta{Name: tt.job}}
+			got := buildChildName(upcxx, tt.suffix)
+			if got != tt.want {
+				t.Errorf("buildChildName(%q, %q) = %q, want %q", tt.job, tt.suffix, got, tt.want)
+			}
+			if len(got) > maxChildNameLength {
+				t.Errorf("buildChildName(%q, %q) is %d characters long", tt.job, tt.suffix, len(got))
+			}
+			if errs := validation.IsDNS1123Label(got + "-0"); len(errs) > 0 {
+				t.Errorf("name of the first worker pod is no DNS label: %v", errs)
+			}
+		})
+	}
+}
+
+func TestBuildChildNameDistinguishesJobs(t *testing.T) {
+	prefix := strings.Repeat("a", 60)
+	first := &pgasv1alpha1.UPCXX{ObjectMeta: meta.ObjectMeta{Name: prefix + "-first"}}
+	second := &pgasv1alpha1.UPCXX{ObjectMeta: meta.ObjectMeta{Name: prefix + "-second"}}
+
+	for _, suffix := range []string{launcherSuffix, workerSuffix, sshAuthSecretSuffix, hostfileSuffix, sharedStorageSuffix} {
+		if buildChildName(first, suffix) == buildChildName(second, suffix) {
+			t.Errorf("jobs sharing a long prefix get the same %s child name", suffix)
+		}
+	}
+}
+
+func TestUPCXXLabelValue(t *testing.T) {
+	long := strings.Repeat("a", 70)
+
+	tests := []struct {
+		name string
+		job  string
+		want string
+	}{
+		{name: "short", job: "job", want: "job"},
+		{name: "fits exactly", job: strings.Repeat("a", 63), want: strings.Repeat("a", 63)},
+		{name: "truncated", job: long, want: strings.Repeat("a", 54) + "-6bd5e503"},
+		{name: "truncated at separator", job: strings.Repeat("a", 53) + "-" + long, want: strings.Repeat("a", 53) + "-63397c31"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pgasv1alpha1.UPCXXLabelValue(tt.job)
+			if got != tt.want {
+				t.Errorf("UPCXXLabelValue(%q) = %q, want %q", tt.job, got, tt.want)
+			}
+			if errs := validation.IsValidLabelValue(got); len(errs) > 0 {
+				t.Errorf("UPCXXLabelValue(%q) is no label value: %v", tt.job, errs)
+			}
+		})
+	}
+}
@@ -0,0 +1,177 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+	"strings"
+
+	core "k8s.io/api/core/v1"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+const (
+	// MPI hostfile specific definitions
+	hostfileSuffix    = "-hostfile"
+	hostfileVolume    = "mpi-hostfile"
+	hostfileKey       = "hostfile"
+	hostfileMountPath = "/etc/mpi"
+	hostfilePath      = hostfileMountPath + "/" + hostfileKey
+)
+
+// getNetwork returns the network configuration of the job with defaults filled in
+func getNetwork(upcxx *pgasv1alpha1.UPCXX) pgasv1alpha1.NetworkSpec {
+	network := pgasv1alpha1.NetworkSpec{}
+	if upcxx.Spec.Network != nil {
+		network = *upcxx.Spec.Network.DeepCopy()
+	}
+
+	if network.Conduit == "" {
+		network.Conduit = pgasv1alpha1.UDPConduit
+	}
+	if network.LaunchMode == "" {
+		network.LaunchMode = pgasv1alpha1.SSHLaunch
+	}
+	if network.Spawner == "" {
+		network.Spawner = pgasv1alpha1.SSHSpawner
+	}
+	if network.LaunchMode == pgasv1alpha1.DirectLaunch {
+		network.Spawner = pgasv1alpha1.PMISpawner
+	} else if network.Conduit == pgasv1alpha1.MPIConduit {
+		network.Spawner = pgasv1alpha1.MPISpawner
+	}
+
+	return network
+}
+
+// validateNetwork rejects conduit and spawner combinations GASNet cannot launch
+func validateNetwork(upcxx *pgasv1alpha1.UPCXX) error {
+	network := getNetwork(upcxx)
+	if network.LaunchMode == pgasv1alpha1.DirectLaunch &&
+		(network.Conduit == pgasv1alpha1.UDPConduit || network.Conduit == pgasv1alpha1.SMPConduit) {
+		return fmt.Errorf("the %s conduit does not support the %s launch mode", network.Conduit, network.LaunchMode)
+	}
+	if network.Conduit == pgasv1alpha1.UDPConduit && network.Spawner == pgasv1alpha1.PMISpawner {
+		return fmt.Errorf("the %s conduit does not support the %s spawner", network.Conduit, network.Spawner)
+	}
+
+	return nil
+}
+
+// usesHostfile tells whether the ranks are started through mpirun and need the MPI hostfile
+func usesHostfile(upcxx *pgasv1alpha1.UPCXX) bool {
+	network := getNetwork(upcxx)
+	return network.Conduit != pgasv1alpha1.SMPConduit && network.Spawner == pgasv1alpha1.MPISpawner
+}
+
+func buildMPIRunCommand(program string) string {
+	return fmt.Sprintf("mpirun -np %%N -hostfile %s %s", hostfilePath, program)
+}
+
+// createNetworkEnvVars selects the conduit and configures its spawner. The user provided
+// environment comes last so that it takes precedence over the generated variables.
+func createNetworkEnvVars(upcxx *pgasv1alpha1.UPCXX) []core.EnvVar {
+	network := getNetwork(upcxx)
+	envVars := []core.EnvVar{
+		{
+			Name:  "UPCXX_NETWORK",
+			Value: string(network.Conduit),
+		},
+	}
+
+	switch network.Conduit {
+	case pgasv1alpha1.UDPConduit:
+		if network.Spawner == pgasv1alpha1.MPISpawner {
+			envVars = append(envVars,
+				core.EnvVar{Name: "GASNET_SPAWNFN", Value: "C"},
+				core.EnvVar{Name: "GASNET_CSPAWN_CMD", Value: buildMPIRunCommand("%C")})
+		} else {
+			envVars = append(envVars, core.EnvVar{Name: "GASNET_SPAWNFN", Value: "S"})
+		}
+	case pgasv1alpha1.MPIConduit:
+		if network.Spawner == pgasv1alpha1.MPISpawner {
+			envVars = append(envVars, core.EnvVar{Name: "MPIRUN_CMD", Value: buildMPIRunCommand("%P %A")})
+		}
+	case pgasv1alpha1.IBVConduit, pgasv1alpha1.OFIConduit:
+		envVars = append(envVars, core.EnvVar{
+			Name:  "GASNET_" + strings.ToUpper(string(network.Conduit)) + "_SPAWNER",
+			Value: string(network.Spawner),
+		})
+		if network.Spawner == pgasv1alpha1.MPISpawner {
+			envVars = append(envVars, core.EnvVar{Name: "MPIRUN_CMD", Value: buildMPIRunCommand("%P %A")})
+		}
+	}
+
+	return append(envVars, network.Env...)
+}
+
+func buildHostfileName(upcxx *pgasv1alpha1.UPCXX) string {
+	return buildChildName(upcxx, hostfileSuffix)
+}
+
+// buildHostfile builds the ConfigMap holding the MPI hostfile with a slot for every rank of a host
+func buildHostfile(upcxx *pgasv1alpha1.UPCXX) *core.ConfigMap {
+	var hostfile strings.Builder
+	for _, host := range buildRankHosts(upcxx) {
+		fmt.Fprintf(&hostfile, "%s slots=%d\n", host, getRanksPerPod(upcxx))
+	}
+
+	return &core.ConfigMap{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      buildHostfileName(upcxx),
+			Namespace: upcxx.Namespace,
+			Labels: map[string]string{
+				"app": buildBaseName(upcxx),
+			},
+			OwnerReferences: []meta.OwnerReference{
+				*meta.NewControllerRef(upcxx, pgasv1alpha1.GroupVersion.WithKind("UPCXX")),
+			},
+		},
+		Data: map[string]string{
+			hostfileKey: hostfile.String(),
+		},
+	}
+}
+
+// setupNetworkOnPod mounts the MPI hostfile into the main container when mpirun starts the ranks
+func setupNetworkOnPod(podSpec *core.PodSpec, upcxx *pgasv1alpha1.UPCXX) {
+	if !usesHostfile(upcxx) {
+		return
+	}
+
+	podSpec.Volumes = append(podSpec.Volumes,
+		core.Volume{
+			Name: hostfileVolume,
+			VolumeSource: core.VolumeSource{
+				ConfigMap: &core.ConfigMapVolumeSource{
+					LocalObjectReference: core.LocalObjectReference{
+						Name: buildHostfileName(upcxx),
+					},
+				},
+			},
+		})
+
+	mainContainer := &podSpec.Containers[0]
+	mainContainer.VolumeMounts = append(mainContainer.VolumeMounts,
+		core.VolumeMount{
+			Name:      hostfileVolume,
+			MountPath: hostfileMountPath,
+			ReadOnly:  true,
+		})
+}
@@ -0,0 +1,84 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"fmt"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// Quota limits the UPCXX jobs running at the same time. Zero values mean unlimited.
+type Quota struct {
+	MaxComputations int32
+	MaxWorkers      int32
+}
+
+// QuotaUsage is the amount of a Quota held by running jobs
+type QuotaUsage struct {
+	Computations int32
+	Workers      int32
+}
+
+// check returns a description of the exceeded limit when adding workerCount workers
+// on top of the usage does not fit into the quota, or an empty string otherwise.
+func (q Quota) check(usage QuotaUsage, workerCount int32) string {
+	if q.MaxComputations > 0 && usage.Computations+1 > q.MaxComputations {
+		return fmt.Sprintf("%d of %d computations in use", usage.Computations, q.MaxComputations)
+	}
+
+	if q.MaxWorkers > 0 && usage.Workers+workerCount > q.MaxWorkers {
+		return fmt.Sprintf("%d of %d workers in use, %d requested", usage.Workers, q.MaxWorkers, workerCount)
+	}
+
+	return ""
+}
+
+// quotaUsage sums up the running jobs in the list, limited to the jobs of owner unless it is empty
+func quotaUsage(upcxxes []pgasv1alpha1.UPCXX, owner string) QuotaUsage {
+	usage := QuotaUsage{}
+	for i := range upcxxes {
+		upcxx := &upcxxes[i]
+		if !upcxx.Status.HoldsQuota() {
+			continue
+		}
+		if owner != "" && upcxx.Labels[pgasv1alpha1.OwnerLabel] != owner {
+			continue
+		}
+
+		usage.Computations++
+		usage.Workers += upcxx.Spec.WorkerCount
+	}
+
+	return usage
+}
+
+// checkQuotas returns a description of the exceeded quota when the job does not fit
+// next to the running jobs, or an empty string otherwise.
+func (r *UPCXXReconciler) checkQuotas(upcxx *pgasv1alpha1.UPCXX, upcxxes []pgasv1alpha1.UPCXX) string {
+	if exceeded := r.NamespaceQuota.check(quotaUsage(upcxxes, ""), upcxx.Spec.WorkerCount); exceeded != "" {
+		return fmt.Sprintf("namespace %s quota exceeded: %s", upcxx.Namespace, exceeded)
+	}
+
+	if owner := upcxx.Labels[pgasv1alpha1.OwnerLabel]; owner != "" {
+		if exceeded := r.UserQuota.check(quotaUsage(upcxxes, owner), upcxx.Spec.WorkerCount); exceeded != "" {
+			return fmt.Sprintf("user %s quota exceeded: %s", owner, exceeded)
+		}
+	}
+
+	return ""
+}
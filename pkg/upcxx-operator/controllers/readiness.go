@@ -0,0 +1,159 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	core "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+const (
+	// How often the readiness of the workers is re-checked while the launcher waits for them
+	workersReadyRequeueInterval = 5 * time.Second
+
+	// Init container of the launcher waiting until the worker hosts resolve. The image only
+	// needs a shell with nslookup and is used when the operator is not given another one.
+	waitForWorkersContainerName = "wait-for-workers"
+	DefaultWaitForWorkersImage  = "busybox:1.34"
+	waitForWorkersScript        = `deadline=$(( $(date +%s) + WAIT_TIMEOUT_SECONDS ))
+for host in $(echo "$WORKER_HOSTS" | tr ',' ' '); do
+  until nslookup "$host" > /dev/null 2>&1; do
+    if [ "$(date +%s)" -ge "$deadline" ]; then
+      echo "$host did not resolve within $WAIT_TIMEOUT_SECONDS seconds"
+      exit 1
+    fi
+    echo "waiting for $host to resolve"
+    sleep 2
+  done
+done`
+
+	// Seconds the launcher waits for the worker hosts to resolve before its pod fails. The
+	// workers are Ready by then, so this only covers the lag of the DNS records.
+	waitForWorkersTimeoutSeconds = 300
+)
+
+// buildWorkerHosts lists the DNS names of the worker pods on the headless worker Service
+func buildWorkerHosts(upcxx *pgasv1alpha1.UPCXX) []string {
+	hosts := buildRankHosts(upcxx)
+	if launcherIsWorker(upcxx) {
+		hosts = hosts[1:]
+	}
+	return hosts
+}
+
+// setupReadinessGateOnPod makes the launcher wait until the records of all workers are published
+// on the headless Service before it starts spawning ranks over ssh. The records only appear once
+// the worker pods are Ready, which the operator already waits for, but may lag behind a bit. When
+// they do not appear within waitForWorkersTimeoutSeconds the launcher pod fails. An empty image
+// stands for DefaultWaitForWorkersImage.
+func setupReadinessGateOnPod(podSpec *core.PodSpec, upcxx *pgasv1alpha1.UPCXX, image string) {
+	if isDirectLaunch(upcxx) {
+		return
+	}
+
+	if image == "" {
+		image = DefaultWaitForWorkersImage
+	}
+
+	podSpec.InitContainers = append(podSpec.InitContainers, core.Container{
+		Name:            waitForWorkersContainerName,
+		Image:           image,
+		ImagePullPolicy: core.PullIfNotPresent,
+		Command:         []string{"sh", "-c", waitForWorkersScript},
+		Env: []core.EnvVar{
+			{
+				Name:  "WORKER_HOSTS",
+				Value: strings.Join(buildWorkerHosts(upcxx), ","),
+			},
+			{
+				Name:  "WAIT_TIMEOUT_SECONDS",
+				Value: strconv.Itoa(waitForWorkersTimeoutSeconds),
+			},
+		},
+	})
+}
+
+// waitForWorkers tells whether all workers of the StatefulSet are Ready, recording the wait in
+// the WorkersReady condition. When the workers do not become Ready within the schedule timeout,
+// the job is failed and its workers are removed, so that a job which cannot be scheduled does
+// not hold its quota and the resources of its scheduled workers forever.
+func (r *UPCXXReconciler) waitForWorkers(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, statefulSet *apps.StatefulSet) (bool, error) {
+	replicas := *getWorkerCount(upcxx)
+
+	waitingSince := upcxx.CreationTimestamp.Time
+	if admitted := apimeta.FindStatusCondition(upcxx.Status.Conditions, pgasv1alpha1.UPCXXAdmitted); admitted != nil {
+		waitingSince = admitted.LastTransitionTime.Time
+	}
+	if attempts := len(upcxx.Status.Attempts); attempts > 0 && upcxx.Status.Attempts[attempts-1].CompletionTime != nil {
+		waitingSince = upcxx.Status.Attempts[attempts-1].CompletionTime.Time
+	}
+
+	if statefulSet.Status.ReadyReplicas >= replicas {
+		if !apimeta.IsStatusConditionTrue(upcxx.Status.Conditions, pgasv1alpha1.UPCXXWorkersReady) {
+			apimeta.SetStatusCondition(&upcxx.Status.Conditions, meta.Condition{
+				Type:    pgasv1alpha1.UPCXXWorkersReady,
+				Status:  meta.ConditionTrue,
+				Reason:  "WorkersReady",
+				Message: fmt.Sprintf("all %d workers are Ready after waiting %v", replicas, time.Since(waitingSince).Round(time.Second)),
+			})
+			return true, r.Client.Status().Update(ctx, upcxx)
+		}
+		return true, nil
+	}
+
+	if time.Since(waitingSince) > getScheduleTimeout(upcxx) {
+		message := fmt.Sprintf("only %d of %d workers became Ready within %v", statefulSet.Status.ReadyReplicas, replicas, getScheduleTimeout(upcxx))
+		r.Recorder.Eventf(upcxx, core.EventTypeWarning, "WorkersNotReady", message)
+
+		if err := r.Client.Delete(ctx, statefulSet); client.IgnoreNotFound(err) != nil {
+			return false, err
+		}
+
+		markFinished(upcxx, pgasv1alpha1.UPCXXFailed, nil)
+		apimeta.SetStatusCondition(&upcxx.Status.Conditions, meta.Condition{
+			Type:    pgasv1alpha1.UPCXXWorkersReady,
+			Status:  meta.ConditionFalse,
+			Reason:  "ScheduleTimeout",
+			Message: message,
+		})
+		return false, r.Client.Status().Update(ctx, upcxx)
+	}
+
+	message := fmt.Sprintf("%d of %d workers are Ready", statefulSet.Status.ReadyReplicas, replicas)
+	if current := apimeta.FindStatusCondition(upcxx.Status.Conditions, pgasv1alpha1.UPCXXWorkersReady); current == nil || current.Message != message {
+		apimeta.SetStatusCondition(&upcxx.Status.Conditions, meta.Condition{
+			Type:    pgasv1alpha1.UPCXXWorkersReady,
+			Status:  meta.ConditionFalse,
+			Reason:  "WaitingForWorkers",
+			Message: message,
+		})
+		return false, r.Client.Status().Update(ctx, upcxx)
+	}
+
+	return false, nil
+}
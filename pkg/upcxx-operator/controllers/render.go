@@ -0,0 +1,101 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"bytes"
+	"fmt"
+
+	"k8s.io/client-go/kubernetes/scheme"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/client/apiutil"
+	"sigs.k8s.io/yaml"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// Placeholder of the SSH keys in rendered manifests, the real keys are generated by the
+// operator when the job is created
+const redactedSSHKey = "<redacted>"
+
+// Render returns the child objects the controller creates for the job, in the order they are
+// created. The job is validated by the same validators as in the controller. The cluster is not
+// touched, so the SSH keys are redacted and the objects lack any fields set by the API server.
+// The launcher waits for the workers with DefaultWaitForWorkersImage.
+func Render(upcxx *pgasv1alpha1.UPCXX) ([]client.Object, error) {
+	if _, err := validateSpec(upcxx); err != nil {
+		return nil, err
+	}
+
+	var children []client.Object
+
+	if !isDirectLaunch(upcxx) {
+		sshAuth, err := newSSHAuthSecret(upcxx)
+		if err != nil {
+			return nil, err
+		}
+		// Moved to data, so that the placeholder is readable instead of base64 encoded
+		sshAuth.Data = map[string]string{}
+		for key := range sshAuth.BinaryData {
+			sshAuth.Data[key] = redactedSSHKey
+		}
+		sshAuth.BinaryData = nil
+		children = append(children, sshAuth)
+	}
+
+	children = append(children, buildLauncherService(upcxx), buildWorkerService(upcxx))
+	if upcxx.Spec.GangScheduling != nil {
+		children = append(children, buildPodGroup(upcxx))
+	}
+	if usesHostfile(upcxx) {
+		children = append(children, buildHostfile(upcxx))
+	}
+	if getStorage(upcxx).Mode == pgasv1alpha1.SharedStorage {
+		children = append(children, buildSharedPVC(upcxx))
+	}
+	children = append(children, buildWorkerStatefulSet(upcxx), buildLauncherJob(upcxx, ""))
+
+	for _, child := range children {
+		gvk, err := apiutil.GVKForObject(child, scheme.Scheme)
+		if err != nil {
+			return nil, err
+		}
+		child.GetObjectKind().SetGroupVersionKind(gvk)
+	}
+
+	return children, nil
+}
+
+// RenderYAML renders the child objects of the job as a multi-document YAML stream
+func RenderYAML(upcxx *pgasv1alpha1.UPCXX) ([]byte, error) {
+	children, err := Render(upcxx)
+	if err != nil {
+		return nil, err
+	}
+
+	var out bytes.Buffer
+	for _, child := range children {
+		data, err := yaml.Marshal(child)
+		if err != nil {
+			return nil, fmt.Errorf("rendering %s %s: %w", child.GetObjectKind().GroupVersionKind().Kind, child.GetName(), err)
+		}
+		out.WriteString("---\n")
+		out.Write(data)
+	}
+
+	return out.Bytes(), nil
+}
@@ -0,0 +1,203 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+const (
+	// How often the teardown of a failed attempt is re-checked before the job is recreated
+	restartRequeueInterval = 5 * time.Second
+
+	defaultMaxRestarts = 3
+
+	// Reason of the Job condition when activeDeadlineSeconds passed
+	jobDeadlineExceeded = "DeadlineExceeded"
+)
+
+// jobFailure describes why an attempt of the job failed
+type jobFailure struct {
+	Reason   string
+	Message  string
+	ExitCode *int32
+
+	// Permanent failures are never restarted, e.g. when the job ran out of time
+	Permanent bool
+}
+
+func getMaxRestarts(upcxx *pgasv1alpha1.UPCXX) int32 {
+	if upcxx.Spec.MaxRestarts != nil {
+		return *upcxx.Spec.MaxRestarts
+	}
+	return defaultMaxRestarts
+}
+
+// shouldRestart applies the restart policy of the job to the failure
+func shouldRestart(upcxx *pgasv1alpha1.UPCXX, failure *jobFailure) bool {
+	if failure.Permanent || upcxx.Status.Restarts >= getMaxRestarts(upcxx) {
+		return false
+	}
+
+	switch upcxx.Spec.RestartPolicy {
+	case pgasv1alpha1.RestartPolicyOnFailure:
+		return true
+	case pgasv1alpha1.RestartPolicyExitCode:
+		return failure.ExitCode == nil || (*failure.ExitCode >= 128 && *failure.ExitCode <= 255)
+	default:
+		return false
+	}
+}
+
+// detectFailure looks for a failed launcher and for worker pods which failed or were restarted.
+// A restarted worker has lost its ranks, so the running attempt cannot succeed anymore.
+func (r *UPCXXReconciler) detectFailure(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, launcherJob *batch.Job) (*jobFailure, error) {
+	if launcherJob.Status.Succeeded > 0 {
+		return nil, nil
+	}
+
+	for _, condition := range launcherJob.Status.Conditions {
+		if condition.Type != batch.JobFailed || condition.Status != core.ConditionTrue {
+			continue
+		}
+
+		if condition.Reason == jobDeadlineExceeded {
+			return &jobFailure{Reason: jobDeadlineExceeded, Message: condition.Message, Permanent: true}, nil
+		}
+
+		failure := &jobFailure{Reason: "LauncherFailed", Message: condition.Message}
+		pods, err := r.listPods(ctx, upcxx, buildLauncherJobName(upcxx))
+		if err != nil {
+			return nil, err
+		}
+		for i := range pods {
+			if exitCode, _ := getFailedExitCode(&pods[i]); exitCode != nil {
+				failure.ExitCode = exitCode
+			}
+		}
+		return failure, nil
+	}
+
+	pods, err := r.listPods(ctx, upcxx, buildWorkerPodName(upcxx))
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range pods {
+		if exitCode, failed := getFailedExitCode(&pods[i]); failed {
+			return &jobFailure{
+				Reason:   "WorkerFailed",
+				Message:  fmt.Sprintf("worker pod %s failed or was restarted", pods[i].Name),
+				ExitCode: exitCode,
+			}, nil
+		}
+	}
+
+	return nil, nil
+}
+
+func (r *UPCXXReconciler) listPods(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, app string) ([]core.Pod, error) {
+	podList := &core.PodList{}
+	err := r.Client.List(ctx, podList, client.InNamespace(upcxx.Namespace), client.MatchingLabels{"app": app})
+	return podList.Items, err
+}
+
+// getFailedExitCode tells whether the pod failed or one of its containers was restarted,
+// along with the exit code of the failed container when it is known
+func getFailedExitCode(pod *core.Pod) (*int32, bool) {
+	failed := pod.Status.Phase == core.PodFailed
+	for _, status := range pod.Status.ContainerStatuses {
+		terminated := status.State.Terminated
+		if terminated == nil || terminated.ExitCode == 0 {
+			terminated = status.LastTerminationState.Terminated
+		}
+
+		if terminated != nil && terminated.ExitCode != 0 {
+			return int32ToPtr(terminated.ExitCode), true
+		}
+		if status.RestartCount > 0 {
+			failed = true
+		}
+	}
+
+	return nil, failed
+}
+
+// handleFailure records the failed attempt and either restarts the job by tearing down its
+// launcher and workers, which are recreated on the next reconcile, or fails the job.
+func (r *UPCXXReconciler) handleFailure(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, failure *jobFailure,
+	launcherJob *batch.Job, statefulSet *apps.StatefulSet) (ctrl.Result, error) {
+	logger := r.Log.WithValues("UPCXX", client.ObjectKeyFromObject(upcxx))
+
+	now := meta.Now()
+	attempt := pgasv1alpha1.UPCXXAttempt{
+		Attempt:        upcxx.Status.Restarts + 1,
+		StartTime:      upcxx.Status.StartTime,
+		CompletionTime: &now,
+		Reason:         failure.Reason,
+		Message:        failure.Message,
+		ExitCode:       failure.ExitCode,
+	}
+	if previous := len(upcxx.Status.Attempts); previous > 0 {
+		attempt.StartTime = upcxx.Status.Attempts[previous-1].CompletionTime
+	}
+	upcxx.Status.Attempts = append(upcxx.Status.Attempts, attempt)
+
+	if !shouldRestart(upcxx, failure) {
+		r.Recorder.Eventf(upcxx, core.EventTypeWarning, failure.Reason, "Job failed: %s", failure.Message)
+		markFinished(upcxx, pgasv1alpha1.UPCXXFailed, &now)
+		if err := r.Client.Status().Update(ctx, upcxx); err != nil {
+			logger.Error(err, "Unable to update UPCXX status")
+			return ctrl.Result{}, err
+		}
+
+		return r.reconcileFinished(ctx, upcxx)
+	}
+
+	// Foreground deletion keeps the objects around until their pods are gone, so that the
+	// next attempt does not start next to pods of the failed one
+	for _, child := range []client.Object{launcherJob, statefulSet} {
+		err := r.Client.Delete(ctx, child, client.PropagationPolicy(meta.DeletePropagationForeground))
+		if client.IgnoreNotFound(err) != nil {
+			logger.Error(err, "Unable to tear down failed attempt")
+			return ctrl.Result{}, err
+		}
+	}
+
+	upcxx.Status.Restarts++
+	apimeta.RemoveStatusCondition(&upcxx.Status.Conditions, pgasv1alpha1.UPCXXWorkersReady)
+	r.Recorder.Eventf(upcxx, core.EventTypeWarning, failure.Reason, "Restarting job after attempt %d failed: %s", attempt.Attempt, failure.Message)
+
+	if err := r.Client.Status().Update(ctx, upcxx); err != nil {
+		logger.Error(err, "Unable to update UPCXX status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{RequeueAfter: restartRequeueInterval}, nil
+}
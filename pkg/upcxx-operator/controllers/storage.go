@@ -0,0 +1,164 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+const (
+	// Name of the storage volume in the pods and of the worker volumeClaimTemplate
+	storageVolume = "data"
+
+	// Shared PVC specific definitions
+	sharedStorageSuffix = "-data"
+
+	defaultStorageMountPath = "/vmount"
+)
+
+var defaultStorageSize = resource.MustParse("1Gi")
+
+//+kubebuilder:rbac:groups="",resources=persistentvolumeclaims,verbs=get;list;watch;create;delete
+
+// getStorage returns the storage configuration of the job with defaults filled in
+func getStorage(upcxx *pgasv1alpha1.UPCXX) pgasv1alpha1.StorageSpec {
+	storage := pgasv1alpha1.StorageSpec{}
+	if upcxx.Spec.Storage != nil {
+		storage = *upcxx.Spec.Storage.DeepCopy()
+	}
+
+	if storage.Mode == "" {
+		storage.Mode = pgasv1alpha1.PerWorkerStorage
+	}
+	if storage.Size == nil {
+		size := defaultStorageSize.DeepCopy()
+		storage.Size = &size
+	}
+	if storage.AccessMode == "" {
+		storage.AccessMode = core.ReadWriteOnce
+		if storage.Mode == pgasv1alpha1.SharedStorage {
+			storage.AccessMode = core.ReadWriteMany
+		}
+	}
+	if storage.MountPath == "" {
+		storage.MountPath = defaultStorageMountPath
+	}
+
+	return storage
+}
+
+func buildSharedPVCName(upcxx *pgasv1alpha1.UPCXX) string {
+	return buildChildName(upcxx, sharedStorageSuffix)
+}
+
+// buildPVC builds the claim used both for the worker volumeClaimTemplates and the shared PVC.
+// PVCs get no OwnerReference, they are deleted by the cleanup finalizer unless retainData is set.
+func buildPVC(upcxx *pgasv1alpha1.UPCXX, name string) core.PersistentVolumeClaim {
+	storage := getStorage(upcxx)
+	return core.PersistentVolumeClaim{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: upcxx.Namespace,
+			Labels: map[string]string{
+				pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
+			},
+		},
+		Spec: core.PersistentVolumeClaimSpec{
+			AccessModes:      []core.PersistentVolumeAccessMode{storage.AccessMode},
+			StorageClassName: storage.StorageClassName,
+			Resources: core.ResourceRequirements{
+				Requests: core.ResourceList{
+					core.ResourceStorage: *storage.Size,
+				},
+			},
+		},
+	}
+}
+
+func buildSharedPVC(upcxx *pgasv1alpha1.UPCXX) *core.PersistentVolumeClaim {
+	pvc := buildPVC(upcxx, buildSharedPVCName(upcxx))
+	return &pvc
+}
+
+func buildVolumeClaimTemplates(upcxx *pgasv1alpha1.UPCXX) []core.PersistentVolumeClaim {
+	if getStorage(upcxx).Mode != pgasv1alpha1.PerWorkerStorage {
+		return nil
+	}
+
+	return []core.PersistentVolumeClaim{buildPVC(upcxx, storageVolume)}
+}
+
+// setupStorageOnPod mounts the job storage into the main container. Worker pods in
+// PerWorker mode get their volume from the StatefulSet volumeClaimTemplates instead.
+func setupStorageOnPod(podSpec *core.PodSpec, upcxx *pgasv1alpha1.UPCXX, isWorker bool) {
+	storage := getStorage(upcxx)
+
+	switch storage.Mode {
+	case pgasv1alpha1.PerWorkerStorage:
+		if !isWorker {
+			return
+		}
+	case pgasv1alpha1.SharedStorage:
+		podSpec.Volumes = append(podSpec.Volumes, core.Volume{
+			Name: storageVolume,
+			VolumeSource: core.VolumeSource{
+				PersistentVolumeClaim: &core.PersistentVolumeClaimVolumeSource{
+					ClaimName: buildSharedPVCName(upcxx),
+				},
+			},
+		})
+	case pgasv1alpha1.EmptyDirStorage:
+		podSpec.Volumes = append(podSpec.Volumes, core.Volume{
+			Name: storageVolume,
+			VolumeSource: core.VolumeSource{
+				EmptyDir: &core.EmptyDirVolumeSource{
+					SizeLimit: storage.Size,
+				},
+			},
+		})
+	}
+
+	mainContainer := &podSpec.Containers[0]
+	mainContainer.VolumeMounts = append(mainContainer.VolumeMounts,
+		core.VolumeMount{
+			Name:      storageVolume,
+			MountPath: storage.MountPath,
+		})
+}
+
+func (r *UPCXXReconciler) getOrCreateSharedPVC(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) error {
+	pvc := &core.PersistentVolumeClaim{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: upcxx.Namespace, Name: buildSharedPVCName(upcxx)}, pvc)
+	if apierrors.IsNotFound(err) {
+		if err := r.Client.Create(ctx, buildSharedPVC(upcxx)); err != nil {
+			return err
+		}
+
+		r.Recorder.Eventf(upcxx, core.EventTypeNormal, "Created shared PVC", buildSharedPVCName(upcxx))
+		return nil
+	}
+
+	return err
+}
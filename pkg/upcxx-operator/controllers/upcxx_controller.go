@@ -29,17 +29,22 @@ import (
 	batch "k8s.io/api/batch/v1"
 	core "k8s.io/api/core/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
-	"k8s.io/apimachinery/pkg/api/resource"
 	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/controller/controllerutil"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
 	"sigs.k8s.io/controller-runtime/pkg/log"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
 
 	"fmt"
+	"strconv"
 	"strings"
 )
 
@@ -93,17 +98,28 @@ type UPCXXReconciler struct {
 	Scheme   *runtime.Scheme
 	Recorder record.EventRecorder
 	Log      logr.Logger
+
+	// Limits on the jobs running at the same time per user and per namespace
+	UserQuota      Quota
+	NamespaceQuota Quota
+
+	// Image of the operator, which runs the verify command in the verifier Jobs
+	VerifierImage string
+
+	// Image of the launcher init container waiting for the worker hosts to resolve,
+	// DefaultWaitForWorkersImage when empty
+	WaitForWorkersImage string
 }
 
 //+kubebuilder:rbac:groups=pgas.github.com,resources=upcxxes,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=pgas.github.com,resources=upcxxes/status,verbs=get;update;patch
 //+kubebuilder:rbac:groups=pgas.github.com,resources=upcxxes/finalizers,verbs=update
 //+kubebuilder:rbac:groups=*,resources=upcxxes,verbs=get;list;watch;create;update;
-//+kubebuilder:rbac:groups=*,resources=configmaps,verbs=get;list;watch;create;update;
-//+kubebuilder:rbac:groups=*,resources=services,verbs=get;list;watch;create;update;
+//+kubebuilder:rbac:groups=*,resources=configmaps,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=*,resources=services,verbs=get;list;watch;create;update;patch;delete
 //+kubebuilder:rbac:groups=*,resources=events,verbs=get;list;watch;create;update;
-//+kubebuilder:rbac:groups=*,resources=statefulsets,verbs=get;list;watch;create;update;
-//+kubebuilder:rbac:groups=*,resources=jobs,verbs=get;list;watch;create;update;
+//+kubebuilder:rbac:groups=*,resources=statefulsets,verbs=get;list;watch;create;update;patch;delete
+//+kubebuilder:rbac:groups=*,resources=jobs,verbs=get;list;watch;create;update;patch;delete
 
 // Reconcile is part of the main kubernetes reconciliation loop which aims to
 // move the current state of the cluster closer to the desired state.
@@ -119,56 +135,106 @@ func (r *UPCXXReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 
 	upcxx := pgasv1alpha1.UPCXX{}
 	if err := r.Client.Get(ctx, req.NamespacedName, &upcxx); err != nil {
-		if apierrors.IsNotFound(err) {
-			return ctrl.Result{}, client.IgnoreNotFound(err)
+		if !apierrors.IsNotFound(err) {
+			logger.Error(err, "Unable to get UPCXX")
 		}
+		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	if _, err := r.getOrCreateSSHAuthSecret(&upcxx); err != nil {
-		logger.Error(err, "creating SSH auth secret")
+	if !upcxx.DeletionTimestamp.IsZero() {
+		return r.finalize(ctx, &upcxx)
 	}
 
-	launcherService := &core.Service{}
-	err := r.Client.Get(ctx, client.ObjectKey{Namespace: upcxx.Namespace, Name: buildLauncherJobName(&upcxx)}, launcherService)
-	if apierrors.IsNotFound(err) {
-		logger.Info("Could not find existing Service for launcher Job")
-
-		launcherService = buildLauncherService(&upcxx)
-		if err := r.Client.Create(ctx, launcherService); err != nil {
-			logger.Error(err, "Unable to create Service for Launcher Job")
+	if !controllerutil.ContainsFinalizer(&upcxx, cleanupFinalizer) {
+		controllerutil.AddFinalizer(&upcxx, cleanupFinalizer)
+		if err := r.Client.Update(ctx, &upcxx); err != nil {
+			logger.Error(err, "Unable to add cleanup finalizer")
 			return ctrl.Result{}, err
 		}
+	}
 
-		r.Recorder.Eventf(&upcxx, core.EventTypeNormal, "Created Service for launcher Job", buildLauncherJobName(&upcxx))
+	if upcxx.Status.IsFinished() {
+		return r.reconcileFinished(ctx, &upcxx)
 	}
 
-	workerService := &core.Service{}
-	err = r.Client.Get(ctx, client.ObjectKey{Namespace: upcxx.Namespace, Name: buildWorkerPodName(&upcxx)}, workerService)
-	if apierrors.IsNotFound(err) {
-		logger.Info("Could not find existing Service for launcher Job")
+	if reason, err := validateSpec(&upcxx); err != nil {
+		return r.failInvalid(ctx, &upcxx, reason, err)
+	}
 
-		workerService = buildWorkerService(&upcxx)
-		if err := r.Client.Create(ctx, workerService); err != nil {
-			logger.Error(err, "Unable to create Service for Launcher Job")
+	if upcxx.Status.IsWaiting() {
+		// Checked only until the job is admitted, the pods mount the input once they are created
+		missing, err := r.checkInputExists(ctx, &upcxx)
+		if err != nil {
+			logger.Error(err, "Unable to get input ConfigMap")
+			return ctrl.Result{}, err
+		}
+		if missing != nil {
+			return r.failInvalid(ctx, &upcxx, "InvalidInput", missing)
+		}
+
+		admitted, err := r.admit(ctx, &upcxx)
+		if err != nil {
+			logger.Error(err, "Unable to admit job")
 			return ctrl.Result{}, err
 		}
 
-		r.Recorder.Eventf(&upcxx, core.EventTypeNormal, "Created Service for worker StatefulSet", buildWorkerPodName(&upcxx))
+		if !admitted {
+			logger.Info("Job is waiting for admission", "phase", upcxx.Status.Phase, "queuePosition", upcxx.Status.QueuePosition)
+			return ctrl.Result{RequeueAfter: admissionRequeueInterval}, nil
+		}
+
+		r.Recorder.Eventf(&upcxx, core.EventTypeNormal, "Admitted", "Job left the admission queue")
 	}
 
-	logger = logger.WithValues("StatefulSetName", upcxx.Spec.StatefulSetName)
-	statefulSet := &apps.StatefulSet{}
-	err = r.Client.Get(ctx, client.ObjectKey{Namespace: upcxx.Namespace, Name: buildWorkerPodName(&upcxx)}, statefulSet)
-	if apierrors.IsNotFound(err) {
-		logger.Info("Could not find existing StatefulSet for", "resource", buildWorkerPodName(&upcxx))
-		statefulSet = buildWorkerStatefulSet(&upcxx)
+	if !isDirectLaunch(&upcxx) {
+		if _, err := r.getOrCreateSSHAuthSecret(&upcxx); err != nil {
+			logger.Error(err, "creating SSH auth secret")
+			return ctrl.Result{}, r.degraded(ctx, &upcxx, "SSHAuthFailed", err)
+		}
+	}
 
-		if err := r.Client.Create(ctx, statefulSet); err != nil {
-			logger.Error(err, "Failed to create StatefulSet", "resource", buildWorkerPodName(&upcxx))
-			return ctrl.Result{}, err
+	if _, err := r.applyChild(ctx, &upcxx, buildLauncherService(&upcxx), "Service for launcher Job"); err != nil {
+		logger.Error(err, "Unable to apply Service for launcher Job")
+		return ctrl.Result{}, r.degraded(ctx, &upcxx, "ServiceFailed", err)
+	}
+
+	if _, err := r.applyChild(ctx, &upcxx, buildWorkerService(&upcxx), "Service for worker StatefulSet"); err != nil {
+		logger.Error(err, "Unable to apply Service for worker StatefulSet")
+		return ctrl.Result{}, r.degraded(ctx, &upcxx, "ServiceFailed", err)
+	}
+
+	if upcxx.Spec.GangScheduling != nil {
+		if _, err := r.applyChild(ctx, &upcxx, buildPodGroup(&upcxx), "PodGroup for worker StatefulSet"); err != nil {
+			logger.Error(err, "Unable to apply PodGroup for worker StatefulSet")
+			return ctrl.Result{}, r.degraded(ctx, &upcxx, "PodGroupFailed", err)
 		}
+	}
+
+	if usesHostfile(&upcxx) {
+		if _, err := r.applyChild(ctx, &upcxx, buildHostfile(&upcxx), "MPI hostfile"); err != nil {
+			logger.Error(err, "Unable to apply MPI hostfile")
+			return ctrl.Result{}, r.degraded(ctx, &upcxx, "HostfileFailed", err)
+		}
+	}
+
+	if getStorage(&upcxx).Mode == pgasv1alpha1.SharedStorage {
+		if err := r.getOrCreateSharedPVC(ctx, &upcxx); err != nil {
+			logger.Error(err, "Unable to create shared PVC")
+			return ctrl.Result{}, r.degraded(ctx, &upcxx, "StorageFailed", err)
+		}
+	}
 
-		r.Recorder.Eventf(&upcxx, core.EventTypeNormal, "Created StatefulSet", buildWorkerPodName(&upcxx))
+	logger = logger.WithValues("StatefulSet", buildWorkerPodName(&upcxx))
+	statefulSet := buildWorkerStatefulSet(&upcxx)
+	terminating, err := r.applyChild(ctx, &upcxx, statefulSet, "StatefulSet")
+	if err != nil {
+		logger.Error(err, "Failed to apply StatefulSet", "resource", buildWorkerPodName(&upcxx))
+		return ctrl.Result{}, r.degraded(ctx, &upcxx, "StatefulSetFailed", err)
+	}
+
+	if terminating {
+		logger.Info("Waiting for the workers of the failed attempt to be deleted")
+		return ctrl.Result{RequeueAfter: restartRequeueInterval}, nil
 	}
 
 	launcherJob := &batch.Job{}
@@ -176,41 +242,115 @@ func (r *UPCXXReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 	if apierrors.IsNotFound(err) {
 		logger.Info("Could not find existing Job for launcher job")
 
-		launcherJob = buildLauncherJob(&upcxx)
-		if err := r.Client.Create(ctx, launcherJob); err != nil {
-			logger.Error(err, "Failed to create Job for launcher pod")
+		ready, err := r.waitForWorkers(ctx, &upcxx, statefulSet)
+		if err != nil {
+			logger.Error(err, "Unable to check readiness of the workers")
 			return ctrl.Result{}, err
 		}
 
-		r.Recorder.Eventf(&upcxx, core.EventTypeNormal, "Created Job for launcher", buildLauncherJobName(&upcxx))
+		if !ready {
+			if upcxx.Status.IsFinished() {
+				return r.reconcileFinished(ctx, &upcxx)
+			}
+
+			logger.Info("Waiting for the workers to become Ready before creating the launcher")
+			return ctrl.Result{RequeueAfter: workersReadyRequeueInterval}, nil
+		}
+
+		// The pod template of a Job is immutable, so the launcher is only applied once
+		launcherJob = buildLauncherJob(&upcxx, r.WaitForWorkersImage)
+		if _, err := r.applyChild(ctx, &upcxx, launcherJob, "Job for launcher"); err != nil {
+			logger.Error(err, "Failed to apply Job for launcher pod")
+			return ctrl.Result{}, r.degraded(ctx, &upcxx, "LauncherJobFailed", err)
+		}
+	} else if err != nil {
+		logger.Error(err, "Unable to get Job for launcher pod")
+		return ctrl.Result{}, r.degraded(ctx, &upcxx, "LauncherJobFailed", err)
+	}
+
+	if err := r.clearDegraded(ctx, &upcxx); err != nil {
+		logger.Error(err, "Unable to update UPCXX status")
+		return ctrl.Result{}, err
+	}
+
+	if launcherJob.DeletionTimestamp != nil {
+		logger.Info("Waiting for the launcher of the failed attempt to be deleted")
+		return ctrl.Result{RequeueAfter: restartRequeueInterval}, nil
+	}
+
+	failure, err := r.detectFailure(ctx, &upcxx, launcherJob)
+	if err != nil {
+		logger.Error(err, "Unable to check the launcher and workers for failures")
+		return ctrl.Result{}, err
+	}
+	if failure != nil {
+		return r.handleFailure(ctx, &upcxx, failure, launcherJob, statefulSet)
+	}
+
+	if phase := getPhaseFromJob(launcherJob); phase != upcxx.Status.Phase {
+		if phase == pgasv1alpha1.UPCXXRunning {
+			upcxx.Status.Phase = phase
+		} else {
+			markFinished(&upcxx, phase, launcherJob.Status.CompletionTime)
+			r.Recorder.Eventf(&upcxx, core.EventTypeNormal, "Job finished", string(phase))
+		}
+
+		if err := r.Client.Status().Update(ctx, &upcxx); err != nil {
+			logger.Error(err, "Unable to update UPCXX status")
+			return ctrl.Result{}, err
+		}
+
+		if upcxx.Status.IsFinished() {
+			return r.reconcileFinished(ctx, &upcxx)
+		}
 	}
 
 	return ctrl.Result{}, nil
 }
 
+func getPhaseFromJob(job *batch.Job) pgasv1alpha1.UPCXXPhase {
+	if job.Status.Succeeded > 0 {
+		return pgasv1alpha1.UPCXXSucceeded
+	}
+
+	for _, condition := range job.Status.Conditions {
+		if condition.Type == batch.JobFailed && condition.Status == core.ConditionTrue {
+			return pgasv1alpha1.UPCXXFailed
+		}
+	}
+
+	return pgasv1alpha1.UPCXXRunning
+}
+
 func buildLauncherJobName(upcxx *pgasv1alpha1.UPCXX) string {
-	return upcxx.Spec.StatefulSetName + launcherSuffix
+	return buildChildName(upcxx, launcherSuffix)
 }
 
-func buildLauncherJob(upcxx *pgasv1alpha1.UPCXX) *batch.Job {
+func buildLauncherJob(upcxx *pgasv1alpha1.UPCXX, waitForWorkersImage string) *batch.Job {
 	controllerRef := *meta.NewControllerRef(upcxx, pgasv1alpha1.GroupVersion.WithKind("UPCXX"))
 	launcherJobSpec := &batch.Job{
 		ObjectMeta: meta.ObjectMeta{
 			Name:      buildLauncherJobName(upcxx),
 			Namespace: upcxx.ObjectMeta.Namespace,
 			Labels: map[string]string{
-				"app": buildLauncherJobName(upcxx),
+				"app":                   buildLauncherJobName(upcxx),
+				pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
 			},
 			OwnerReferences: []meta.OwnerReference{controllerRef},
 		},
 		Spec: batch.JobSpec{
-			BackoffLimit: int32ToPtr(1),
+			BackoffLimit:          getBackoffLimit(upcxx),
+			ActiveDeadlineSeconds: upcxx.Spec.ActiveDeadlineSeconds,
 			Template: core.PodTemplateSpec{
 				ObjectMeta: meta.ObjectMeta{
 					Name: buildLauncherJobName(upcxx),
 					Labels: map[string]string{
-						"app": buildLauncherJobName(upcxx),
-						"hpc": "upcxx",
+						"app":                   buildLauncherJobName(upcxx),
+						"hpc":                   "upcxx",
+						pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
+					},
+					Annotations: map[string]string{
+						pgasv1alpha1.UPCXXAnnotation: upcxx.Name,
 					},
 				},
 				Spec: core.PodSpec{
@@ -235,13 +375,18 @@ func buildLauncherJob(upcxx *pgasv1alpha1.UPCXX) *batch.Job {
 	}
 
 	//launcherJobSpec.Spec.Template.Spec.Containers[0].Env = append(launcherJobSpec.Spec.Template.Spec.Containers[0].Env, createEnvVars(upcxx)...)
-	setupSSHOnPod(&launcherJobSpec.Spec.Template.Spec, upcxx)
+	setupLaunchOnPod(&launcherJobSpec.Spec.Template.Spec, upcxx, false)
+	setupAlgorithmOnPod(&launcherJobSpec.Spec.Template.Spec, upcxx, false)
+	setupReadinessGateOnPod(&launcherJobSpec.Spec.Template.Spec, upcxx, waitForWorkersImage)
+	setupStorageOnPod(&launcherJobSpec.Spec.Template.Spec, upcxx, false)
+	setupInputOnPod(&launcherJobSpec.Spec.Template.Spec, upcxx)
+	setupNetworkOnPod(&launcherJobSpec.Spec.Template.Spec, upcxx)
 
 	return launcherJobSpec
 }
 
 func buildWorkerPodName(upcxx *pgasv1alpha1.UPCXX) string {
-	return upcxx.Spec.StatefulSetName + workerSuffix
+	return buildChildName(upcxx, workerSuffix)
 }
 
 func buildWorkerStatefulSet(upcxx *pgasv1alpha1.UPCXX) *apps.StatefulSet {
@@ -250,8 +395,12 @@ func buildWorkerStatefulSet(upcxx *pgasv1alpha1.UPCXX) *apps.StatefulSet {
 	statefulSet := apps.StatefulSet{
 		ObjectMeta: meta.ObjectMeta{
 			// TODO: Should we pass sts name in the yaml? It can be same as the resource name or with -sts postfix.
-			Name:            buildWorkerPodName(upcxx),
-			Namespace:       upcxx.Namespace,
+			Name:      buildWorkerPodName(upcxx),
+			Namespace: upcxx.Namespace,
+			Labels: map[string]string{
+				"app":                   buildWorkerPodName(upcxx),
+				pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
+			},
 			OwnerReferences: []meta.OwnerReference{controllerRef},
 		},
 		Spec: apps.StatefulSetSpec{
@@ -266,20 +415,16 @@ func buildWorkerStatefulSet(upcxx *pgasv1alpha1.UPCXX) *apps.StatefulSet {
 				ObjectMeta: meta.ObjectMeta{
 					Name: buildWorkerPodName(upcxx),
 					Labels: map[string]string{
-						"app": buildWorkerPodName(upcxx),
-						"hpc": "upcxx",
+						"app":                   buildWorkerPodName(upcxx),
+						"hpc":                   "upcxx",
+						pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
+					},
+					Annotations: map[string]string{
+						pgasv1alpha1.UPCXXAnnotation: upcxx.Name,
 					},
 				},
 				Spec: core.PodSpec{
 					Hostname: buildWorkerPodName(upcxx),
-					Volumes: []core.Volume{
-						{
-							Name: "empty-dir-vm",
-							VolumeSource: core.VolumeSource{
-								EmptyDir: &core.EmptyDirVolumeSource{},
-							},
-						},
-					},
 					Containers: []core.Container{
 						{
 							Name:  UPCXXContainerName,
@@ -291,12 +436,6 @@ func buildWorkerStatefulSet(upcxx *pgasv1alpha1.UPCXX) *apps.StatefulSet {
 									ContainerPort: 80,
 								},
 							},
-							VolumeMounts: []core.VolumeMount{
-								{
-									Name:      upcxx.Spec.StatefulSetName + "-vm",
-									MountPath: "/vmount",
-								},
-							},
 							SecurityContext: &core.SecurityContext{
 								RunAsUser:              int64ToPtr(1000),
 								RunAsGroup:             int64ToPtr(1000),
@@ -306,75 +445,121 @@ func buildWorkerStatefulSet(upcxx *pgasv1alpha1.UPCXX) *apps.StatefulSet {
 					},
 				},
 			},
-			VolumeClaimTemplates: []core.PersistentVolumeClaim{
-				{
-					ObjectMeta: meta.ObjectMeta{
-						Name:            upcxx.Spec.StatefulSetName + "-vm",
-						Namespace:       upcxx.Namespace,
-						OwnerReferences: []meta.OwnerReference{controllerRef},
-					},
-					Spec: core.PersistentVolumeClaimSpec{
-						AccessModes: []core.PersistentVolumeAccessMode{
-							core.ReadWriteMany,
-						},
-						Resources: core.ResourceRequirements{
-							Requests: core.ResourceList{
-								core.ResourceStorage: *resource.NewQuantity(500, resource.BinarySI),
-							},
-						},
-					},
-				},
-			},
+			VolumeClaimTemplates: buildVolumeClaimTemplates(upcxx),
 		},
 	}
 
 	statefulSet.Spec.Template.Spec.Containers[0].Env = append(statefulSet.Spec.Template.Spec.Containers[0].Env, createEnvVars(upcxx)...)
-	setupSSHOnPod(&statefulSet.Spec.Template.Spec, upcxx)
+	setupLaunchOnPod(&statefulSet.Spec.Template.Spec, upcxx, true)
+	setupAlgorithmOnPod(&statefulSet.Spec.Template.Spec, upcxx, true)
+	setupStorageOnPod(&statefulSet.Spec.Template.Spec, upcxx, true)
+	setupInputOnPod(&statefulSet.Spec.Template.Spec, upcxx)
+	setupNetworkOnPod(&statefulSet.Spec.Template.Spec, upcxx)
+	if upcxx.Spec.GangScheduling != nil {
+		setupGangSchedulingOnPod(&statefulSet.Spec.Template, upcxx)
+	}
 
 	return &statefulSet
 }
 
-func createSSHServersEnv(upcxx *pgasv1alpha1.UPCXX) (core.EnvVar, []string) {
-	var sshServersList []string
-	sshServersList = append(sshServersList, buildLauncherJobName(upcxx))
+// buildRankHosts lists the hosts running ranks: the launcher when it is a worker, then the workers
+func buildRankHosts(upcxx *pgasv1alpha1.UPCXX) []string {
+	var hosts []string
+	if launcherIsWorker(upcxx) {
+		hosts = append(hosts, buildLauncherJobName(upcxx))
+	}
+
 	workerName := buildWorkerPodName(upcxx)
-	for idx := int32(0); idx < upcxx.Spec.WorkerCount-1; idx++ {
-		sshServersList = append(sshServersList, fmt.Sprintf("%s-%d.%s.%s", workerName, idx, workerName, "default.svc.cluster.local"))
+	for idx := int32(0); idx < *getWorkerCount(upcxx); idx++ {
+		hosts = append(hosts, fmt.Sprintf("%s-%d.%s.%s.svc.cluster.local", workerName, idx, workerName, upcxx.Namespace))
 	}
 
-	return core.EnvVar{Name: "SSH_SERVERS", Value: strings.Join(sshServersList, ",")}, sshServersList
+	return hosts
+}
+
+// createSSHServersEnv lists every host once per rank it runs, which is how the GASNet ssh
+// spawner is told how many ranks to place on a host
+func createSSHServersEnv(upcxx *pgasv1alpha1.UPCXX) core.EnvVar {
+	var sshServersList []string
+	for _, host := range buildRankHosts(upcxx) {
+		for rank := int32(0); rank < getRanksPerPod(upcxx); rank++ {
+			sshServersList = append(sshServersList, host)
+		}
+	}
+
+	return core.EnvVar{Name: "SSH_SERVERS", Value: strings.Join(sshServersList, ",")}
 }
 
 func createEnvVars(upcxx *pgasv1alpha1.UPCXX) []core.EnvVar {
-	sshServersEnv, sshServerList := createSSHServersEnv(upcxx)
-	return []core.EnvVar{
-		sshServersEnv,
+	envVars := []core.EnvVar{
 		{
-			Name:  "GASNET_SSH_SERVERS",
-			Value: sshServersEnv.Value,
+			Name:  "UPCXX_RANKS",
+			Value: strconv.Itoa(int(getRankCount(upcxx))),
 		},
 		{
-			Name:  "GASNET_MASTERIP",
-			Value: sshServerList[0],
-		},
-		{
-			Name:  "UPCXX_NETWORK",
-			Value: "udp",
-		},
-		{
-			Name:  "GASNET_SPAWNFN",
-			Value: "S",
+			Name:  "UPCXX_RANKS_PER_POD",
+			Value: strconv.Itoa(int(getRanksPerPod(upcxx))),
 		},
 	}
+
+	if !isDirectLaunch(upcxx) {
+		sshServersEnv := createSSHServersEnv(upcxx)
+		envVars = append(envVars,
+			sshServersEnv,
+			core.EnvVar{
+				Name:  "GASNET_SSH_SERVERS",
+				Value: sshServersEnv.Value,
+			})
+	}
+
+	return append(envVars, createNetworkEnvVars(upcxx)...)
 }
 
+func getBackoffLimit(upcxx *pgasv1alpha1.UPCXX) *int32 {
+	if upcxx.Spec.BackoffLimit != nil {
+		return int32ToPtr(*upcxx.Spec.BackoffLimit)
+	}
+	return int32ToPtr(1)
+}
+
+// getWorkerCount returns the number of worker pods. The launcher takes the place of one
+// of them when it runs ranks too.
 func getWorkerCount(upcxx *pgasv1alpha1.UPCXX) *int32 {
-	workerCount := upcxx.Spec.WorkerCount - 1
+	workerCount := upcxx.Spec.WorkerCount
+	if launcherIsWorker(upcxx) {
+		workerCount--
+	}
 	return &workerCount
 }
 
+func launcherIsWorker(upcxx *pgasv1alpha1.UPCXX) bool {
+	return upcxx.Spec.LauncherIsWorker == nil || *upcxx.Spec.LauncherIsWorker
+}
+
+func getRanksPerPod(upcxx *pgasv1alpha1.UPCXX) int32 {
+	if upcxx.Spec.RanksPerPod != nil {
+		return *upcxx.Spec.RanksPerPod
+	}
+	return 1
+}
+
+// getRankCount returns the total number of ranks of the job
+func getRankCount(upcxx *pgasv1alpha1.UPCXX) int32 {
+	return upcxx.Spec.WorkerCount * getRanksPerPod(upcxx)
+}
+
 func buildLauncherService(upcxx *pgasv1alpha1.UPCXX) *core.Service {
-	return newService(upcxx, buildLauncherJobName(upcxx))
+	service := newService(upcxx, buildLauncherJobName(upcxx))
+	if isDirectLaunch(upcxx) {
+		// Every port must be named once the Service exposes more than one
+		service.Spec.Ports[0].Name = "http"
+		service.Spec.Ports = append(service.Spec.Ports, core.ServicePort{
+			Name: rendezvousPortName,
+			Port: rendezvousPort,
+		})
+	}
+
+	return service
 }
 
 func buildWorkerService(upcxx *pgasv1alpha1.UPCXX) *core.Service {
@@ -387,7 +572,8 @@ func newService(upcxx *pgasv1alpha1.UPCXX, name string) *core.Service {
 			Name:      name,
 			Namespace: upcxx.Namespace,
 			Labels: map[string]string{
-				"app": name,
+				"app":                   name,
+				pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
 			},
 			OwnerReferences: []meta.OwnerReference{
 				*meta.NewControllerRef(upcxx, pgasv1alpha1.GroupVersion.WithKind("UPCXX")),
@@ -407,12 +593,16 @@ func newService(upcxx *pgasv1alpha1.UPCXX, name string) *core.Service {
 	}
 }
 
+func buildSSHAuthSecretName(upcxx *pgasv1alpha1.UPCXX) string {
+	return buildChildName(upcxx, sshAuthSecretSuffix)
+}
+
 // getOrCreateSSHAuthSecret gets the Secret holding the SSH auth for this job,
 // or create one if it doesn't exist.
 func (r *UPCXXReconciler) getOrCreateSSHAuthSecret(job *pgasv1alpha1.UPCXX) (*core.ConfigMap, error) {
 	secret := &core.ConfigMap{}
 	err := r.Get(context.TODO(), client.ObjectKey{Namespace: job.Namespace,
-		Name: job.Spec.StatefulSetName + sshAuthSecretSuffix}, secret)
+		Name: buildSSHAuthSecretName(job)}, secret)
 	if apierrors.IsNotFound(err) {
 		secret, err = newSSHAuthSecret(job)
 		if err != nil {
@@ -421,6 +611,8 @@ func (r *UPCXXReconciler) getOrCreateSSHAuthSecret(job *pgasv1alpha1.UPCXX) (*co
 		if err := r.Create(context.TODO(), secret); err != nil {
 			return nil, err
 		}
+
+		return secret, nil
 	}
 
 	if err != nil {
@@ -453,10 +645,10 @@ func newSSHAuthSecret(job *pgasv1alpha1.UPCXX) (*core.ConfigMap, error) {
 
 	return &core.ConfigMap{
 		ObjectMeta: meta.ObjectMeta{
-			Name:      job.Spec.StatefulSetName + sshAuthSecretSuffix,
+			Name:      buildSSHAuthSecretName(job),
 			Namespace: job.Namespace,
 			Labels: map[string]string{
-				"app": job.Spec.StatefulSetName,
+				"app": buildBaseName(job),
 			},
 			OwnerReferences: []meta.OwnerReference{
 				*meta.NewControllerRef(job, pgasv1alpha1.GroupVersion.WithKind("UPCXX")),
@@ -481,7 +673,7 @@ func setupSSHOnPod(podSpec *core.PodSpec, job *pgasv1alpha1.UPCXX) {
 				ConfigMap: &core.ConfigMapVolumeSource{
 					DefaultMode: mode,
 					LocalObjectReference: core.LocalObjectReference{
-						Name: job.Spec.StatefulSetName + sshAuthSecretSuffix,
+						Name: buildSSHAuthSecretName(job),
 					},
 					Items: sshVolumeItems,
 				},
@@ -509,5 +701,28 @@ func int64ToPtr(i int64) *int64 {
 func (r *UPCXXReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&pgasv1alpha1.UPCXX{}).
+		Owns(&batch.Job{}).
+		Owns(&apps.StatefulSet{}).
+		Owns(&core.Service{}).
+		Owns(&core.ConfigMap{}).
+		Watches(&source.Kind{Type: &core.Pod{}}, handler.EnqueueRequestsFromMapFunc(mapPodToUPCXX)).
 		Complete(r)
 }
+
+// mapPodToUPCXX enqueues the UPCXX of a launcher, worker or verifier pod. The pods are owned by
+// the Jobs and the StatefulSet rather than by the UPCXX, so they are matched through the
+// annotation holding the name of the UPCXX. Pods created before the annotation was added carry
+// the full name in their label.
+func mapPodToUPCXX(pod client.Object) []reconcile.Request {
+	name, ok := pod.GetAnnotations()[pgasv1alpha1.UPCXXAnnotation]
+	if !ok {
+		name, ok = pod.GetLabels()[pgasv1alpha1.UPCXXLabel]
+	}
+	if !ok {
+		return nil
+	}
+
+	return []reconcile.Request{
+		{NamespacedName: types.NamespacedName{Namespace: pod.GetNamespace(), Name: name}},
+	}
+}
@@ -0,0 +1,605 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	apps "k8s.io/api/apps/v1"
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	apimeta "k8s.io/apimachinery/pkg/api/meta"
+	"k8s.io/apimachinery/pkg/api/resource"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+	pgasv1beta1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1beta1"
+)
+
+const (
+	timeout  = 10 * time.Second
+	interval = 250 * time.Millisecond
+)
+
+// createReadyNode registers a schedulable node, since envtest runs no kubelets and
+// jobs are only admitted when the cluster has room for all of their pods
+func createReadyNode(ctx context.Context, name string) {
+	node := &core.Node{ObjectMeta: meta.ObjectMeta{Name: name}}
+	Expect(k8sClient.Create(ctx, node)).To(Succeed())
+
+	node.Status = core.NodeStatus{
+		Allocatable: core.ResourceList{
+			core.ResourceCPU:    resource.MustParse("64"),
+			core.ResourceMemory: resource.MustParse("256Gi"),
+			core.ResourcePods:   resource.MustParse("110"),
+		},
+		Conditions: []core.NodeCondition{
+			{Type: core.NodeReady, Status: core.ConditionTrue},
+		},
+	}
+	Expect(k8sClient.Status().Update(ctx, node)).To(Succeed())
+}
+
+func newUPCXX(name string) *pgasv1alpha1.UPCXX {
+	return &pgasv1alpha1.UPCXX{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      name,
+			Namespace: "default",
+		},
+		Spec: pgasv1alpha1.UPCXXSpec{
+			WorkerCount: 2,
+			Algorithm:   glconstants.Kruskal,
+		},
+	}
+}
+
+func getUPCXX(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) func() pgasv1alpha1.UPCXXStatus {
+	return func() pgasv1alpha1.UPCXXStatus {
+		current := &pgasv1alpha1.UPCXX{}
+		if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(upcxx), current); err != nil {
+			return pgasv1alpha1.UPCXXStatus{}
+		}
+		return current.Status
+	}
+}
+
+func getChild(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, name string, obj client.Object) {
+	Eventually(func() error {
+		return k8sClient.Get(ctx, types.NamespacedName{Namespace: upcxx.Namespace, Name: name}, obj)
+	}, timeout, interval).Should(Succeed())
+}
+
+func expectControlledBy(obj client.Object, upcxx *pgasv1alpha1.UPCXX) {
+	owner := meta.GetControllerOf(obj)
+	Expect(owner).NotTo(BeNil())
+	Expect(owner.Kind).To(Equal("UPCXX"))
+	Expect(owner.Name).To(Equal(upcxx.Name))
+}
+
+func envValue(container core.Container, name string) string {
+	for _, env := range container.Env {
+		if env.Name == name {
+			return env.Value
+		}
+	}
+	return ""
+}
+
+func volumeNames(podSpec core.PodSpec) []string {
+	var names []string
+	for _, volume := range podSpec.Volumes {
+		names = append(names, volume.Name)
+	}
+	return names
+}
+
+// markWorkersReady simulates the StatefulSet controller reporting all workers as Ready
+func markWorkersReady(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) {
+	Eventually(func() error {
+		statefulSet := &apps.StatefulSet{}
+		key := types.NamespacedName{Namespace: upcxx.Namespace, Name: buildWorkerPodName(upcxx)}
+		if err := k8sClient.Get(ctx, key, statefulSet); err != nil {
+			return err
+		}
+
+		statefulSet.Status.Replicas = *statefulSet.Spec.Replicas
+		statefulSet.Status.ReadyReplicas = *statefulSet.Spec.Replicas
+		return k8sClient.Status().Update(ctx, statefulSet)
+	}, timeout, interval).Should(Succeed())
+}
+
+// markJobSucceeded simulates the Job controller reporting a completed Job
+func markJobSucceeded(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, name string) {
+	Eventually(func() error {
+		job := &batch.Job{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Namespace: upcxx.Namespace, Name: name}, job); err != nil {
+			return err
+		}
+
+		now := meta.Now()
+		job.Status = batch.JobStatus{
+			StartTime:      &now,
+			CompletionTime: &now,
+			Succeeded:      1,
+			Conditions: []batch.JobCondition{
+				{Type: batch.JobComplete, Status: core.ConditionTrue},
+			},
+		}
+		return k8sClient.Status().Update(ctx, job)
+	}, timeout, interval).Should(Succeed())
+}
+
+// createVerifierPod simulates a verifier pod which terminated with the given report
+func createVerifierPod(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, report string) {
+	pod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      buildVerifierJobName(upcxx) + "-abcde",
+			Namespace: upcxx.Namespace,
+			Labels: map[string]string{
+				"app":                   buildVerifierJobName(upcxx),
+				pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
+			},
+			Annotations: map[string]string{
+				pgasv1alpha1.UPCXXAnnotation: upcxx.Name,
+			},
+		},
+		Spec: core.PodSpec{
+			Containers: []core.Container{{Name: verifierContainerName, Image: "controller:latest"}},
+		},
+	}
+	Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+	pod.Status = core.PodStatus{
+		Phase: core.PodSucceeded,
+		ContainerStatuses: []core.ContainerStatus{
+			{
+				Name:  verifierContainerName,
+				Image: "controller:latest",
+				State: core.ContainerState{
+					Terminated: &core.ContainerStateTerminated{ExitCode: 0, Reason: "Completed", Message: report},
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+}
+
+// createRestartedWorker simulates a worker pod whose container was killed and restarted
+func createRestartedWorker(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, exitCode int32) {
+	pod := &core.Pod{
+		ObjectMeta: meta.ObjectMeta{
+			Name:      buildWorkerPodName(upcxx) + "-0",
+			Namespace: upcxx.Namespace,
+			Labels: map[string]string{
+				"app":                   buildWorkerPodName(upcxx),
+				pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
+			},
+			Annotations: map[string]string{
+				pgasv1alpha1.UPCXXAnnotation: upcxx.Name,
+			},
+		},
+		Spec: core.PodSpec{
+			Containers: []core.Container{{Name: UPCXXContainerName, Image: UPCXXLatestContainerName}},
+		},
+	}
+	Expect(k8sClient.Create(ctx, pod)).To(Succeed())
+
+	pod.Status = core.PodStatus{
+		Phase: core.PodRunning,
+		ContainerStatuses: []core.ContainerStatus{
+			{
+				Name:         UPCXXContainerName,
+				Image:        UPCXXLatestContainerName,
+				RestartCount: 1,
+				State:        core.ContainerState{Running: &core.ContainerStateRunning{}},
+				LastTerminationState: core.ContainerState{
+					Terminated: &core.ContainerStateTerminated{ExitCode: exitCode, Reason: "Error"},
+				},
+			},
+		},
+	}
+	Expect(k8sClient.Status().Update(ctx, pod)).To(Succeed())
+}
+
+var _ = Describe("UPCXX controller", func() {
+	ctx := context.Background()
+
+	BeforeEach(func() {
+		node := &core.Node{}
+		if err := k8sClient.Get(ctx, types.NamespacedName{Name: "node-1"}, node); err != nil {
+			createReadyNode(ctx, "node-1")
+		}
+	})
+
+	Context("when a child object is deleted", func() {
+		It("recreates the worker StatefulSet", func() {
+			upcxx := newUPCXX("recreate-sts")
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			key := types.NamespacedName{Namespace: upcxx.Namespace, Name: buildWorkerPodName(upcxx)}
+			statefulSet := &apps.StatefulSet{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, key, statefulSet)
+			}, timeout, interval).Should(Succeed())
+
+			originalUID := statefulSet.UID
+			Expect(k8sClient.Delete(ctx, statefulSet)).To(Succeed())
+
+			Eventually(func() (types.UID, error) {
+				recreated := &apps.StatefulSet{}
+				err := k8sClient.Get(ctx, key, recreated)
+				return recreated.UID, err
+			}, timeout, interval).ShouldNot(Equal(originalUID))
+		})
+
+		It("recreates the worker Service", func() {
+			upcxx := newUPCXX("recreate-svc")
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			key := types.NamespacedName{Namespace: upcxx.Namespace, Name: buildWorkerPodName(upcxx)}
+			service := &core.Service{}
+			Eventually(func() error {
+				return k8sClient.Get(ctx, key, service)
+			}, timeout, interval).Should(Succeed())
+
+			originalUID := service.UID
+			Expect(k8sClient.Delete(ctx, service)).To(Succeed())
+
+			Eventually(func() (types.UID, error) {
+				recreated := &core.Service{}
+				err := k8sClient.Get(ctx, key, recreated)
+				return recreated.UID, err
+			}, timeout, interval).ShouldNot(Equal(originalUID))
+		})
+	})
+
+	Context("when a job is admitted", func() {
+		It("creates the Services, SSH material and worker StatefulSet", func() {
+			upcxx := newUPCXX("children")
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			Eventually(func() pgasv1alpha1.UPCXXPhase {
+				return getUPCXX(ctx, upcxx)().Phase
+			}, timeout, interval).Should(Equal(pgasv1alpha1.UPCXXRunning))
+
+			status := getUPCXX(ctx, upcxx)()
+			Expect(status.StartTime).NotTo(BeNil())
+			Expect(status.Ranks).To(Equal(int32(2)))
+			Expect(apimeta.IsStatusConditionTrue(status.Conditions, pgasv1alpha1.UPCXXAdmitted)).To(BeTrue())
+
+			for _, name := range []string{buildLauncherJobName(upcxx), buildWorkerPodName(upcxx)} {
+				service := &core.Service{}
+				getChild(ctx, upcxx, name, service)
+				expectControlledBy(service, upcxx)
+				Expect(service.Spec.ClusterIP).To(Equal(core.ClusterIPNone))
+				Expect(service.Spec.Selector).To(HaveKeyWithValue("app", name))
+			}
+
+			sshAuth := &core.ConfigMap{}
+			getChild(ctx, upcxx, buildSSHAuthSecretName(upcxx), sshAuth)
+			expectControlledBy(sshAuth, upcxx)
+			Expect(sshAuth.BinaryData).To(HaveKey(core.SSHAuthPrivateKey))
+			Expect(sshAuth.BinaryData).To(HaveKey(sshPublicKey))
+
+			statefulSet := &apps.StatefulSet{}
+			getChild(ctx, upcxx, buildWorkerPodName(upcxx), statefulSet)
+			expectControlledBy(statefulSet, upcxx)
+			Expect(*statefulSet.Spec.Replicas).To(Equal(int32(1)))
+			Expect(statefulSet.Spec.Template.Labels).To(HaveKeyWithValue(pgasv1alpha1.UPCXXLabel, upcxx.Name))
+
+			container := statefulSet.Spec.Template.Spec.Containers[0]
+			Expect(envValue(container, "UPCXX_NETWORK")).To(Equal("udp"))
+			Expect(envValue(container, "GASNET_SPAWNFN")).To(Equal("S"))
+			Expect(envValue(container, "UPCXX_RANKS")).To(Equal("2"))
+			Expect(envValue(container, "SSH_SERVERS")).To(HavePrefix(buildLauncherJobName(upcxx) + ","))
+			Expect(volumeNames(statefulSet.Spec.Template.Spec)).To(ContainElement(sshAuthVolume))
+			Expect(statefulSet.Spec.VolumeClaimTemplates).To(HaveLen(1))
+			Expect(statefulSet.Spec.VolumeClaimTemplates[0].Name).To(Equal(storageVolume))
+			Expect(statefulSet.Spec.VolumeClaimTemplates[0].Labels).To(HaveKeyWithValue(pgasv1alpha1.UPCXXLabel, upcxx.Name))
+		})
+
+		It("waits for the workers before creating the launcher Job", func() {
+			upcxx := newUPCXX("launcher")
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			getChild(ctx, upcxx, buildWorkerPodName(upcxx), &apps.StatefulSet{})
+			Eventually(func() bool {
+				condition := apimeta.FindStatusCondition(getUPCXX(ctx, upcxx)().Conditions, pgasv1alpha1.UPCXXWorkersReady)
+				return condition != nil && condition.Status == meta.ConditionFalse
+			}, timeout, interval).Should(BeTrue())
+
+			job := &batch.Job{}
+			Consistently(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Namespace: upcxx.Namespace, Name: buildLauncherJobName(upcxx)}, job)
+			}, time.Second, interval).ShouldNot(Succeed())
+
+			markWorkersReady(ctx, upcxx)
+
+			getChild(ctx, upcxx, buildLauncherJobName(upcxx), job)
+			expectControlledBy(job, upcxx)
+			Expect(*job.Spec.BackoffLimit).To(Equal(int32(1)))
+			Expect(job.Spec.Template.Spec.InitContainers).To(HaveLen(1))
+			Expect(job.Spec.Template.Spec.InitContainers[0].Name).To(Equal(waitForWorkersContainerName))
+			Expect(volumeNames(job.Spec.Template.Spec)).To(ContainElement(sshAuthVolume))
+			Expect(apimeta.IsStatusConditionTrue(getUPCXX(ctx, upcxx)().Conditions, pgasv1alpha1.UPCXXWorkersReady)).To(BeTrue())
+		})
+	})
+
+	Context("when the name of the job does not fit into a label value", func() {
+		It("creates its children with shortened names and labels", func() {
+			upcxx := newUPCXX("long-" + strings.Repeat("a", 65))
+			upcxx.Spec.Storage = &pgasv1alpha1.StorageSpec{Mode: pgasv1alpha1.SharedStorage}
+			Expect(len(upcxx.Name)).To(Equal(70))
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			label := pgasv1alpha1.UPCXXLabelValue(upcxx.Name)
+			Expect(validation.IsValidLabelValue(label)).To(BeEmpty())
+
+			pvc := &core.PersistentVolumeClaim{}
+			getChild(ctx, upcxx, buildSharedPVCName(upcxx), pvc)
+			Expect(pvc.Labels).To(HaveKeyWithValue(pgasv1alpha1.UPCXXLabel, label))
+
+			statefulSet := &apps.StatefulSet{}
+			getChild(ctx, upcxx, buildWorkerPodName(upcxx), statefulSet)
+			Expect(statefulSet.Labels).To(HaveKeyWithValue(pgasv1alpha1.UPCXXLabel, label))
+			Expect(statefulSet.Spec.Template.Annotations).To(HaveKeyWithValue(pgasv1alpha1.UPCXXAnnotation, upcxx.Name))
+
+			for _, name := range []string{buildLauncherJobName(upcxx), buildWorkerPodName(upcxx)} {
+				service := &core.Service{}
+				getChild(ctx, upcxx, name, service)
+				Expect(service.Labels).To(HaveKeyWithValue(pgasv1alpha1.UPCXXLabel, label))
+			}
+
+			markWorkersReady(ctx, upcxx)
+
+			job := &batch.Job{}
+			getChild(ctx, upcxx, buildLauncherJobName(upcxx), job)
+			Expect(job.Labels).To(HaveKeyWithValue(pgasv1alpha1.UPCXXLabel, label))
+			Expect(job.Spec.Template.Annotations).To(HaveKeyWithValue(pgasv1alpha1.UPCXXAnnotation, upcxx.Name))
+
+			// Pods are mapped back to the job through the annotation holding its full name
+			createRestartedWorker(ctx, upcxx, 1)
+			Eventually(func() pgasv1alpha1.UPCXXPhase {
+				return getUPCXX(ctx, upcxx)().Phase
+			}, timeout, interval).Should(Equal(pgasv1alpha1.UPCXXFailed))
+		})
+	})
+
+	Context("when the launcher Job finishes", func() {
+		It("moves the job into the Succeeded phase", func() {
+			upcxx := newUPCXX("succeeded")
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			getChild(ctx, upcxx, buildWorkerPodName(upcxx), &apps.StatefulSet{})
+			markWorkersReady(ctx, upcxx)
+			getChild(ctx, upcxx, buildLauncherJobName(upcxx), &batch.Job{})
+			markJobSucceeded(ctx, upcxx, buildLauncherJobName(upcxx))
+
+			Eventually(func() pgasv1alpha1.UPCXXPhase {
+				return getUPCXX(ctx, upcxx)().Phase
+			}, timeout, interval).Should(Equal(pgasv1alpha1.UPCXXSucceeded))
+			Expect(getUPCXX(ctx, upcxx)().CompletionTime).NotTo(BeNil())
+
+			// The workers are scaled down by the same field manager which created them
+			statefulSet := &apps.StatefulSet{}
+			Eventually(func() int32 {
+				getChild(ctx, upcxx, buildWorkerPodName(upcxx), statefulSet)
+				return *statefulSet.Spec.Replicas
+			}, timeout, interval).Should(Equal(int32(0)))
+			for _, entry := range statefulSet.ManagedFields {
+				if entry.Operation == meta.ManagedFieldsOperationUpdate {
+					Expect(entry.Manager).NotTo(Equal(fieldManager))
+				}
+			}
+		})
+	})
+
+	Context("when the result of a succeeded job is verified", func() {
+		It("records the verdict of the verifier", func() {
+			upcxx := newUPCXX("verified")
+			upcxx.Spec.Storage = &pgasv1alpha1.StorageSpec{Mode: pgasv1alpha1.SharedStorage}
+			upcxx.Spec.Verify = &pgasv1alpha1.VerifySpec{InputPath: "graph.txt", ResultPath: "out/mst.txt"}
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			getChild(ctx, upcxx, buildWorkerPodName(upcxx), &apps.StatefulSet{})
+			markWorkersReady(ctx, upcxx)
+			getChild(ctx, upcxx, buildLauncherJobName(upcxx), &batch.Job{})
+			markJobSucceeded(ctx, upcxx, buildLauncherJobName(upcxx))
+
+			verifier := &batch.Job{}
+			getChild(ctx, upcxx, buildVerifierJobName(upcxx), verifier)
+			expectControlledBy(verifier, upcxx)
+			container := verifier.Spec.Template.Spec.Containers[0]
+			Expect(container.Args).To(ContainElements("verify", "-algorithm=kruskal", "-input=/vmount/graph.txt", "-result=/vmount/out/mst.txt"))
+			Expect(volumeNames(verifier.Spec.Template.Spec)).To(ContainElement(storageVolume))
+			Expect(verifier.Spec.Template.Labels).To(HaveKeyWithValue("hpc", "upcxx"))
+
+			Eventually(func() *pgasv1alpha1.VerificationStatus {
+				return getUPCXX(ctx, upcxx)().Verification
+			}, timeout, interval).ShouldNot(BeNil())
+			Expect(getUPCXX(ctx, upcxx)().Verification.Verdict).To(Equal(pgasv1alpha1.VerificationPending))
+
+			createVerifierPod(ctx, upcxx, `{"verdict":"Passed","message":"total weight 10 and all 5 edges match the reference tree"}`)
+			markJobSucceeded(ctx, upcxx, buildVerifierJobName(upcxx))
+
+			Eventually(func() pgasv1alpha1.VerificationVerdict {
+				return getUPCXX(ctx, upcxx)().Verification.Verdict
+			}, timeout, interval).Should(Equal(pgasv1alpha1.VerificationPassed))
+			Expect(getUPCXX(ctx, upcxx)().Verification.Message).To(ContainSubstring("total weight 10"))
+		})
+
+		It("verifies the input of the job", func() {
+			configMap := &core.ConfigMap{
+				ObjectMeta: meta.ObjectMeta{Name: "verified-input-graph", Namespace: "default"},
+				Data:       map[string]string{"graph.txt": "0 1 1\n1 2 2\n"},
+			}
+			Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+
+			upcxx := newUPCXX("verified-input")
+			upcxx.Spec.Storage = &pgasv1alpha1.StorageSpec{Mode: pgasv1alpha1.SharedStorage}
+			upcxx.Spec.Input = &pgasv1alpha1.InputSpec{
+				ConfigMap: core.ConfigMapKeySelector{
+					LocalObjectReference: core.LocalObjectReference{Name: configMap.Name},
+					Key:                  "graph.txt",
+				},
+				Format: "edgelist",
+			}
+			upcxx.Spec.Verify = &pgasv1alpha1.VerifySpec{ResultPath: "mst.txt"}
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			getChild(ctx, upcxx, buildWorkerPodName(upcxx), &apps.StatefulSet{})
+			markWorkersReady(ctx, upcxx)
+			getChild(ctx, upcxx, buildLauncherJobName(upcxx), &batch.Job{})
+			markJobSucceeded(ctx, upcxx, buildLauncherJobName(upcxx))
+
+			verifier := &batch.Job{}
+			getChild(ctx, upcxx, buildVerifierJobName(upcxx), verifier)
+			container := verifier.Spec.Template.Spec.Containers[0]
+			Expect(container.Args).To(ContainElements("-input=/input/graph.txt", "-format=edgelist", "-result=/vmount/mst.txt"))
+			Expect(container.Args).NotTo(ContainElement("-directed"))
+			Expect(volumeNames(verifier.Spec.Template.Spec)).To(ContainElements(storageVolume, inputVolume))
+		})
+
+		It("fails a job which cannot be verified", func() {
+			upcxx := newUPCXX("unverifiable")
+			upcxx.Spec.Verify = &pgasv1alpha1.VerifySpec{InputPath: "graph.txt", ResultPath: "mst.txt"}
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			Eventually(func() pgasv1alpha1.UPCXXPhase {
+				return getUPCXX(ctx, upcxx)().Phase
+			}, timeout, interval).Should(Equal(pgasv1alpha1.UPCXXFailed))
+		})
+	})
+
+	Context("when the input of a job does not exist", func() {
+		It("fails the job without a ConfigMap", func() {
+			upcxx := newUPCXX("missing-input")
+			upcxx.Spec.Input = &pgasv1alpha1.InputSpec{
+				ConfigMap: core.ConfigMapKeySelector{
+					LocalObjectReference: core.LocalObjectReference{Name: "missing-input-graph"},
+					Key:                  "graph.txt",
+				},
+			}
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			Eventually(func() pgasv1alpha1.UPCXXPhase {
+				return getUPCXX(ctx, upcxx)().Phase
+			}, timeout, interval).Should(Equal(pgasv1alpha1.UPCXXFailed))
+		})
+
+		It("fails the job when the ConfigMap lacks the key", func() {
+			configMap := &core.ConfigMap{
+				ObjectMeta: meta.ObjectMeta{Name: "missing-key-graph", Namespace: "default"},
+				Data:       map[string]string{"other.txt": "0 1\n"},
+			}
+			Expect(k8sClient.Create(ctx, configMap)).To(Succeed())
+
+			upcxx := newUPCXX("missing-key")
+			upcxx.Spec.Input = &pgasv1alpha1.InputSpec{
+				ConfigMap: core.ConfigMapKeySelector{
+					LocalObjectReference: core.LocalObjectReference{Name: configMap.Name},
+					Key:                  "graph.txt",
+				},
+			}
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			Eventually(func() pgasv1alpha1.UPCXXPhase {
+				return getUPCXX(ctx, upcxx)().Phase
+			}, timeout, interval).Should(Equal(pgasv1alpha1.UPCXXFailed))
+			Consistently(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Namespace: upcxx.Namespace, Name: buildWorkerPodName(upcxx)}, &apps.StatefulSet{})
+			}, time.Second, interval).ShouldNot(Succeed())
+		})
+	})
+
+	Context("when a job sets fields the operator does not support", func() {
+		It("rejects the job", func() {
+			upcxx := &pgasv1beta1.UPCXX{
+				ObjectMeta: meta.ObjectMeta{Name: "unsupported-output", Namespace: "default"},
+				Spec: pgasv1beta1.UPCXXSpec{
+					Algorithm: glconstants.Kruskal,
+					Output:    &pgasv1beta1.DataSink{PersistentVolumeClaim: &pgasv1beta1.VolumeFileSource{ClaimName: "results"}},
+					Workers:   pgasv1beta1.WorkersSpec{Count: 2},
+				},
+			}
+			Expect(k8sClient.Create(ctx, upcxx)).NotTo(Succeed())
+
+			// The fields v1alpha1 keeps in an annotation are validated after the conversion
+			legacy := newUPCXX("unsupported-input")
+			legacy.Annotations = map[string]string{
+				"pgas.github.com/v1beta1-data": `{"input": {"url": "https://example.com/graph.txt"}}`,
+			}
+			Expect(k8sClient.Create(ctx, legacy)).NotTo(Succeed())
+		})
+	})
+
+	Context("when a worker is restarted", func() {
+		It("fails the job with the Never restart policy", func() {
+			upcxx := newUPCXX("worker-failed")
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			getChild(ctx, upcxx, buildWorkerPodName(upcxx), &apps.StatefulSet{})
+			markWorkersReady(ctx, upcxx)
+			getChild(ctx, upcxx, buildLauncherJobName(upcxx), &batch.Job{})
+
+			createRestartedWorker(ctx, upcxx, 137)
+
+			Eventually(func() pgasv1alpha1.UPCXXPhase {
+				return getUPCXX(ctx, upcxx)().Phase
+			}, timeout, interval).Should(Equal(pgasv1alpha1.UPCXXFailed))
+
+			status := getUPCXX(ctx, upcxx)()
+			Expect(status.Restarts).To(BeZero())
+			Expect(status.Attempts).To(HaveLen(1))
+			Expect(status.Attempts[0].Reason).To(Equal("WorkerFailed"))
+			Expect(*status.Attempts[0].ExitCode).To(Equal(int32(137)))
+		})
+
+		It("restarts the job with the OnFailure restart policy", func() {
+			upcxx := newUPCXX("worker-restarted")
+			upcxx.Spec.RestartPolicy = pgasv1alpha1.RestartPolicyOnFailure
+			Expect(k8sClient.Create(ctx, upcxx)).To(Succeed())
+
+			getChild(ctx, upcxx, buildWorkerPodName(upcxx), &apps.StatefulSet{})
+			markWorkersReady(ctx, upcxx)
+			getChild(ctx, upcxx, buildLauncherJobName(upcxx), &batch.Job{})
+
+			createRestartedWorker(ctx, upcxx, 137)
+
+			Eventually(func() int32 {
+				return getUPCXX(ctx, upcxx)().Restarts
+			}, timeout, interval).Should(Equal(int32(1)))
+
+			status := getUPCXX(ctx, upcxx)()
+			Expect(status.Phase).To(Equal(pgasv1alpha1.UPCXXRunning))
+			Expect(status.Attempts).To(HaveLen(1))
+
+			// envtest runs no garbage collector, so the foreground deletion never completes
+			statefulSet := &apps.StatefulSet{}
+			getChild(ctx, upcxx, buildWorkerPodName(upcxx), statefulSet)
+			Expect(statefulSet.DeletionTimestamp).NotTo(BeNil())
+		})
+	})
+})
@@ -0,0 +1,62 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+
+	core "k8s.io/api/core/v1"
+	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+// specValidators check the spec of a job before any child is created, in this order. A job
+// failing one of them is failed with the reason of the validator as the event reason.
+var specValidators = []struct {
+	reason   string
+	validate func(*pgasv1alpha1.UPCXX) error
+}{
+	{"InvalidNetwork", validateNetwork},
+	{"InvalidAlgorithm", validateAlgorithm},
+	{"InvalidVerify", validateVerify},
+	{"InvalidInput", validateInput},
+}
+
+// validateSpec runs the validators on the job and returns the reason and error of the first one
+// that fails
+func validateSpec(upcxx *pgasv1alpha1.UPCXX) (string, error) {
+	for _, validator := range specValidators {
+		if err := validator.validate(upcxx); err != nil {
+			return validator.reason, err
+		}
+	}
+	return "", nil
+}
+
+// failInvalid fails a job whose spec can never run, recording the error as a warning event
+func (r *UPCXXReconciler) failInvalid(ctx context.Context, upcxx *pgasv1alpha1.UPCXX, reason string, err error) (ctrl.Result, error) {
+	r.Recorder.Eventf(upcxx, core.EventTypeWarning, reason, "%v", err)
+	markFinished(upcxx, pgasv1alpha1.UPCXXFailed, nil)
+	if err := r.Client.Status().Update(ctx, upcxx); err != nil {
+		r.Log.WithValues("UPCXX", client.ObjectKeyFromObject(upcxx)).Error(err, "Unable to update UPCXX status")
+		return ctrl.Result{}, err
+	}
+
+	return ctrl.Result{}, nil
+}
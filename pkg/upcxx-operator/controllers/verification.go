@@ -0,0 +1,247 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package controllers
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"path"
+	"sort"
+	"strings"
+
+	batch "k8s.io/api/batch/v1"
+	core "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	meta "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+
+	"github.com/lnikon/glfs-pkg/pkg/reference"
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+)
+
+const (
+	verifierSuffix        = "-verify"
+	verifierContainerName = "verifier"
+
+	defaultVerifyMaxEdges = 100000
+)
+
+// validateVerify checks that the verifier is able to read the input and the result of the job
+func validateVerify(upcxx *pgasv1alpha1.UPCXX) error {
+	verify := upcxx.Spec.Verify
+	if verify == nil {
+		return nil
+	}
+
+	if getStorage(upcxx).Mode != pgasv1alpha1.SharedStorage {
+		return fmt.Errorf("verify requires the %s storage mode", pgasv1alpha1.SharedStorage)
+	}
+
+	paths := []string{verify.ResultPath}
+	if upcxx.Spec.Input == nil {
+		paths = append(paths, verify.InputPath)
+	} else if verify.InputPath != "" {
+		return errors.New("verify inputPath must be empty when the job has an input, which is verified instead")
+	}
+	for _, p := range paths {
+		if p == "" || path.IsAbs(p) || strings.HasPrefix(path.Clean(p), "..") {
+			return fmt.Errorf("verify path %q must be relative to the storage mount path", p)
+		}
+	}
+
+	return nil
+}
+
+func buildVerifierJobName(upcxx *pgasv1alpha1.UPCXX) string {
+	return buildChildName(upcxx, verifierSuffix)
+}
+
+// buildVerifierArgs passes the job to the verify command of the operator image. The input of
+// the job is verified when it has one, otherwise the edge list at the input path of the storage.
+func buildVerifierArgs(upcxx *pgasv1alpha1.UPCXX) []string {
+	verify := upcxx.Spec.Verify
+	mountPath := getStorage(upcxx).MountPath
+
+	maxEdges := int64(defaultVerifyMaxEdges)
+	if verify.MaxEdges != nil {
+		maxEdges = *verify.MaxEdges
+	}
+
+	args := []string{
+		"verify",
+		"-algorithm=" + string(upcxx.Spec.Algorithm),
+	}
+	if input := upcxx.Spec.Input; input != nil {
+		args = append(args, "-input="+buildInputPath(upcxx))
+		if input.Format != "" {
+			args = append(args, "-format="+input.Format)
+		}
+		if input.Directed {
+			args = append(args, "-directed")
+		}
+	} else {
+		args = append(args, "-input="+path.Join(mountPath, verify.InputPath))
+		if verify.Directed {
+			args = append(args, "-directed")
+		}
+	}
+	args = append(args,
+		"-result="+path.Join(mountPath, verify.ResultPath),
+		fmt.Sprintf("-max-edges=%d", maxEdges),
+	)
+
+	var params []string
+	for name, value := range upcxx.Spec.Parameters {
+		params = append(params, fmt.Sprintf("-param=%s=%s", name, value))
+	}
+	sort.Strings(params)
+	return append(args, params...)
+}
+
+// buildVerifierJob runs the reference implementation of the algorithm on the shared storage and
+// the input of the job. The report is passed back through the termination message of the container.
+func buildVerifierJob(upcxx *pgasv1alpha1.UPCXX, image string) *batch.Job {
+	controllerRef := *meta.NewControllerRef(upcxx, pgasv1alpha1.GroupVersion.WithKind("UPCXX"))
+	labels := map[string]string{
+		"app":                   buildVerifierJobName(upcxx),
+		pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
+	}
+
+	job := &batch.Job{
+		ObjectMeta: meta.ObjectMeta{
+			Name:            buildVerifierJobName(upcxx),
+			Namespace:       upcxx.Namespace,
+			Labels:          labels,
+			OwnerReferences: []meta.OwnerReference{controllerRef},
+		},
+		Spec: batch.JobSpec{
+			BackoffLimit: int32ToPtr(0),
+			Template: core.PodTemplateSpec{
+				ObjectMeta: meta.ObjectMeta{
+					// Selected along with the launcher and worker pods when the logs are read
+					Labels: map[string]string{
+						"app":                   buildVerifierJobName(upcxx),
+						"hpc":                   "upcxx",
+						pgasv1alpha1.UPCXXLabel: pgasv1alpha1.UPCXXLabelValue(upcxx.Name),
+					},
+					Annotations: map[string]string{
+						pgasv1alpha1.UPCXXAnnotation: upcxx.Name,
+					},
+				},
+				Spec: core.PodSpec{
+					Containers: []core.Container{
+						{
+							Name:                     verifierContainerName,
+							Image:                    image,
+							Command:                  []string{"/manager"},
+							Args:                     buildVerifierArgs(upcxx),
+							TerminationMessagePolicy: core.TerminationMessageFallbackToLogsOnError,
+						},
+					},
+					RestartPolicy: core.RestartPolicyNever,
+				},
+			},
+		},
+	}
+
+	setupStorageOnPod(&job.Spec.Template.Spec, upcxx, false)
+	setupInputOnPod(&job.Spec.Template.Spec, upcxx)
+	return job
+}
+
+// reconcileVerification runs the verifier Job of a succeeded job and records its verdict in the
+// status. It reports whether the verification is over, or was not requested at all.
+func (r *UPCXXReconciler) reconcileVerification(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) (bool, error) {
+	if upcxx.Spec.Verify == nil || upcxx.Status.Phase != pgasv1alpha1.UPCXXSucceeded {
+		return true, nil
+	}
+	if verification := upcxx.Status.Verification; verification != nil && verification.Verdict != pgasv1alpha1.VerificationPending {
+		return true, nil
+	}
+
+	job := &batch.Job{}
+	err := r.Client.Get(ctx, client.ObjectKey{Namespace: upcxx.Namespace, Name: buildVerifierJobName(upcxx)}, job)
+	if apierrors.IsNotFound(err) {
+		// The pod template of a Job is immutable, so the verifier is only applied once
+		if _, err := r.applyChild(ctx, upcxx, buildVerifierJob(upcxx, r.VerifierImage), "verifier Job"); err != nil {
+			return false, err
+		}
+
+		upcxx.Status.Verification = &pgasv1alpha1.VerificationStatus{Verdict: pgasv1alpha1.VerificationPending}
+		return false, r.Client.Status().Update(ctx, upcxx)
+	} else if err != nil {
+		return false, err
+	}
+
+	phase := getPhaseFromJob(job)
+	if phase == pgasv1alpha1.UPCXXRunning {
+		return false, nil
+	}
+
+	message, err := r.getVerifierMessage(ctx, upcxx)
+	if err != nil {
+		return false, err
+	}
+
+	now := meta.Now()
+	verification := &pgasv1alpha1.VerificationStatus{
+		Verdict:        pgasv1alpha1.VerificationError,
+		Message:        message,
+		CompletionTime: &now,
+	}
+	if phase == pgasv1alpha1.UPCXXSucceeded {
+		report := reference.Report{}
+		if err := json.Unmarshal([]byte(message), &report); err != nil {
+			verification.Message = fmt.Sprintf("unable to parse the report of the verifier: %v", err)
+		} else {
+			verification.Verdict = pgasv1alpha1.VerificationVerdict(report.Verdict)
+			verification.Message = report.Message
+		}
+	} else if message == "" {
+		verification.Message = "verifier Job failed"
+	}
+	upcxx.Status.Verification = verification
+
+	eventType := core.EventTypeNormal
+	if verification.Verdict == pgasv1alpha1.VerificationFailed || verification.Verdict == pgasv1alpha1.VerificationError {
+		eventType = core.EventTypeWarning
+	}
+	r.Recorder.Eventf(upcxx, eventType, "Verification"+string(verification.Verdict), "%s", verification.Message)
+
+	return true, r.Client.Status().Update(ctx, upcxx)
+}
+
+// getVerifierMessage returns the termination message of the verifier container, empty when
+// the container did not terminate with one
+func (r *UPCXXReconciler) getVerifierMessage(ctx context.Context, upcxx *pgasv1alpha1.UPCXX) (string, error) {
+	pods := &core.PodList{}
+	if err := r.Client.List(ctx, pods, client.InNamespace(upcxx.Namespace), client.MatchingLabels{"app": buildVerifierJobName(upcxx)}); err != nil {
+		return "", err
+	}
+
+	for _, pod := range pods.Items {
+		for _, status := range pod.Status.ContainerStatuses {
+			if status.State.Terminated != nil && status.State.Terminated.Message != "" {
+				return strings.TrimSpace(status.State.Terminated.Message), nil
+			}
+		}
+	}
+
+	return "", nil
+}
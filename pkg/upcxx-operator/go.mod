@@ -4,7 +4,10 @@ go 1.17
 
 require (
 	github.com/go-logr/logr v0.4.0
+	github.com/google/gofuzz v1.2.0
 	github.com/lnikon/glfs-pkg/pkg/constants v0.0.0-20211103152516-cac955b50b84
+	github.com/lnikon/glfs-pkg/pkg/graph v0.0.0-00010101000000-000000000000
+	github.com/lnikon/glfs-pkg/pkg/reference v0.0.0-00010101000000-000000000000
 	github.com/onsi/ginkgo v1.16.5
 	github.com/onsi/gomega v1.16.0
 	golang.org/x/crypto v0.0.0-20211215153901-e495a2d5b3d3
@@ -13,9 +16,14 @@ require (
 	k8s.io/client-go v0.22.3
 	k8s.io/code-generator v0.22.4
 	sigs.k8s.io/controller-runtime v0.10.2
+	sigs.k8s.io/yaml v1.3.0
 )
 
-//replace github.com/lnikon/glfs-pkg/pkg/constants => ../constants
+replace (
+	github.com/lnikon/glfs-pkg/pkg/constants => ../constants
+	github.com/lnikon/glfs-pkg/pkg/graph => ../graph
+	github.com/lnikon/glfs-pkg/pkg/reference => ../reference
+)
 
 require (
 	cloud.google.com/go v0.65.0 // indirect
@@ -42,7 +50,6 @@ require (
 	github.com/golang/groupcache v0.0.0-20210331224755-41bb18bfe9da // indirect
 	github.com/golang/protobuf v1.5.2 // indirect
 	github.com/google/go-cmp v0.5.6 // indirect
-	github.com/google/gofuzz v1.2.0 // indirect
 	github.com/google/uuid v1.1.2 // indirect
 	github.com/googleapis/gnostic v0.5.5 // indirect
 	github.com/imdario/mergo v0.3.12 // indirect
@@ -85,5 +92,4 @@ require (
 	k8s.io/kube-openapi v0.0.0-20211109043538-20434351676c // indirect
 	k8s.io/utils v0.0.0-20210930125809-cb0fa318a74b // indirect
 	sigs.k8s.io/structured-merge-diff/v4 v4.1.2 // indirect
-	sigs.k8s.io/yaml v1.3.0 // indirect
 )
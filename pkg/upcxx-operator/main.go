@@ -18,6 +18,7 @@ package main
 
 import (
 	"flag"
+	"fmt"
 	"os"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
@@ -32,6 +33,7 @@ import (
 	"sigs.k8s.io/controller-runtime/pkg/log/zap"
 
 	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+	pgasv1beta1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1beta1"
 	"github.com/lnikon/glfs-pkg/pkg/upcxx-operator/controllers"
 	//+kubebuilder:scaffold:imports
 )
@@ -45,18 +47,51 @@ func init() {
 	utilruntime.Must(clientgoscheme.AddToScheme(scheme))
 
 	utilruntime.Must(pgasv1alpha1.AddToScheme(scheme))
+	utilruntime.Must(pgasv1beta1.AddToScheme(scheme))
 	//+kubebuilder:scaffold:scheme
 }
 
 func main() {
+	if len(os.Args) > 1 && os.Args[1] == "render" {
+		if err := render(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+	if len(os.Args) > 1 && os.Args[1] == "verify" {
+		if err := verify(os.Args[2:]); err != nil {
+			fmt.Fprintln(os.Stderr, err)
+			os.Exit(1)
+		}
+		return
+	}
+
 	var metricsAddr string
 	var enableLeaderElection bool
 	var probeAddr string
+	var maxUserComputations, maxUserWorkers int
+	var maxNamespaceComputations, maxNamespaceWorkers int
+	var verifierImage string
+	var waitForWorkersImage string
 	flag.StringVar(&metricsAddr, "metrics-bind-address", ":8080", "The address the metric endpoint binds to.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", ":8081", "The address the probe endpoint binds to.")
 	flag.BoolVar(&enableLeaderElection, "leader-elect", false,
 		"Enable leader election for controller manager. "+
 			"Enabling this will ensure there is only one active controller manager.")
+	flag.IntVar(&maxUserComputations, "max-computations-per-user", 0,
+		"Maximum number of UPCXX jobs a single user may run at the same time. Zero means unlimited.")
+	flag.IntVar(&maxUserWorkers, "max-workers-per-user", 0,
+		"Maximum number of workers a single user may run at the same time. Zero means unlimited.")
+	flag.IntVar(&maxNamespaceComputations, "max-computations-per-namespace", 0,
+		"Maximum number of UPCXX jobs running at the same time in a namespace. Zero means unlimited.")
+	flag.IntVar(&maxNamespaceWorkers, "max-workers-per-namespace", 0,
+		"Maximum number of workers running at the same time in a namespace. Zero means unlimited.")
+	flag.StringVar(&verifierImage, "verifier-image", "upcxx-controller:latest",
+		"Image of the operator, used to verify the results of jobs against the reference implementations. "+
+			"Defaults to the image the Makefile builds and deploys.")
+	flag.StringVar(&waitForWorkersImage, "wait-for-workers-image", controllers.DefaultWaitForWorkersImage,
+		"Image with a shell and nslookup, used by the launcher to wait until the worker hosts resolve.")
 	opts := zap.Options{
 		Development: true,
 	}
@@ -83,10 +118,26 @@ func main() {
 		Scheme:   mgr.GetScheme(),
 		Recorder: mgr.GetEventRecorderFor("upcxx-controller"),
 		Log:      ctrl.Log.WithName("controllers").WithName("UPCXX"),
+		UserQuota: controllers.Quota{
+			MaxComputations: int32(maxUserComputations),
+			MaxWorkers:      int32(maxUserWorkers),
+		},
+		NamespaceQuota: controllers.Quota{
+			MaxComputations: int32(maxNamespaceComputations),
+			MaxWorkers:      int32(maxNamespaceWorkers),
+		},
+		VerifierImage:       verifierImage,
+		WaitForWorkersImage: waitForWorkersImage,
 	}).SetupWithManager(mgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "UPCXX")
 		os.Exit(1)
 	}
+	if os.Getenv("ENABLE_WEBHOOKS") != "false" {
+		if err = (&pgasv1beta1.UPCXX{}).SetupWebhookWithManager(mgr); err != nil {
+			setupLog.Error(err, "unable to create webhook", "webhook", "UPCXX")
+			os.Exit(1)
+		}
+	}
 	//+kubebuilder:scaffold:builder
 
 	if err := mgr.AddHealthzCheck("healthz", healthz.Ping); err != nil {
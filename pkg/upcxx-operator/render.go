@@ -0,0 +1,73 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"flag"
+	"fmt"
+	"io"
+	"os"
+
+	"sigs.k8s.io/yaml"
+
+	pgasv1alpha1 "github.com/lnikon/glfs-pkg/pkg/upcxx-operator/api/v1alpha1"
+	"github.com/lnikon/glfs-pkg/pkg/upcxx-operator/controllers"
+)
+
+// render prints the objects the operator would create for a UPCXX manifest, without
+// connecting to a cluster:
+//
+//	manager render -f config/samples/pgas_v1alpha1_upcxx.yaml
+func render(args []string) error {
+	flags := flag.NewFlagSet("render", flag.ExitOnError)
+	var file string
+	flags.StringVar(&file, "f", "-", "The UPCXX manifest to render, - reads it from stdin.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	input := os.Stdin
+	if file != "-" {
+		f, err := os.Open(file)
+		if err != nil {
+			return err
+		}
+		defer f.Close()
+		input = f
+	}
+
+	data, err := io.ReadAll(input)
+	if err != nil {
+		return err
+	}
+
+	upcxx := &pgasv1alpha1.UPCXX{}
+	if err := yaml.UnmarshalStrict(data, upcxx); err != nil {
+		return fmt.Errorf("parsing UPCXX manifest: %w", err)
+	}
+	if upcxx.Namespace == "" {
+		upcxx.Namespace = "default"
+	}
+
+	manifests, err := controllers.RenderYAML(upcxx)
+	if err != nil {
+		return err
+	}
+
+	_, err = os.Stdout.Write(manifests)
+	return err
+}
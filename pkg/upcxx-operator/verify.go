@@ -0,0 +1,146 @@
+/*
+Copyright 2021.
+
+Licensed under the Apache License, Version 2.0 (the "License");
+you may not use this file except in compliance with the License.
+You may obtain a copy of the License at
+
+    http://www.apache.org/licenses/LICENSE-2.0
+
+Unless required by applicable law or agreed to in writing, software
+distributed under the License is distributed on an "AS IS" BASIS,
+WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+See the License for the specific language governing permissions and
+limitations under the License.
+*/
+
+package main
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	glconstants "github.com/lnikon/glfs-pkg/pkg/constants"
+	glgraph "github.com/lnikon/glfs-pkg/pkg/graph"
+	"github.com/lnikon/glfs-pkg/pkg/reference"
+)
+
+// parameters collects repeated -param name=value flags
+type parameters map[string]string
+
+func (p parameters) String() string {
+	return fmt.Sprint(map[string]string(p))
+}
+
+func (p parameters) Set(value string) error {
+	parts := strings.SplitN(value, "=", 2)
+	if len(parts) != 2 {
+		return fmt.Errorf("expected name=value, got %q", value)
+	}
+	p[parts[0]] = parts[1]
+	return nil
+}
+
+// verify checks the result of a job against the reference implementation of its algorithm.
+// It runs in the verifier Job created by the operator, which reads the report from the
+// termination message of the container:
+//
+//	manager verify -algorithm prim -param root=3 -input /vmount/graph.txt -result /vmount/mst.txt
+func verify(args []string) error {
+	flags := flag.NewFlagSet("verify", flag.ExitOnError)
+	params := parameters{}
+	var algorithm, input, format, result, reportPath string
+	var directed bool
+	var maxEdges int
+	flags.StringVar(&algorithm, "algorithm", "", "The algorithm that produced the result.")
+	flags.Var(params, "param", "A name=value parameter of the algorithm, may be repeated.")
+	flags.StringVar(&input, "input", "", "The input graph of the job.")
+	flags.StringVar(&format, "format", "", "The format of the input graph, detected from the contents when empty.")
+	flags.StringVar(&result, "result", "", "The result written by the job.")
+	flags.BoolVar(&directed, "directed", false, "Whether the edges of an input edge list are directed, other formats declare it themselves.")
+	flags.IntVar(&maxEdges, "max-edges", 100000, "Inputs with more edges are skipped.")
+	flags.StringVar(&reportPath, "report", "/dev/termination-log", "Where the JSON report is written.")
+	if err := flags.Parse(args); err != nil {
+		return err
+	}
+
+	inputFile, err := os.Open(input)
+	if err != nil {
+		return err
+	}
+	defer inputFile.Close()
+
+	edges, directed, err := readInput(inputFile, format, directed, maxEdges)
+	if err != nil {
+		return fmt.Errorf("reading input %s: %w", input, err)
+	}
+
+	report := reference.Report{
+		Verdict: reference.Skipped,
+		Message: fmt.Sprintf("input has more than the %d edges verified", maxEdges),
+	}
+	if len(edges) <= maxEdges {
+		resultFile, err := os.Open(result)
+		if err != nil {
+			return err
+		}
+		defer resultFile.Close()
+
+		report, err = reference.Verify(glconstants.Algorithm(algorithm), params, directed, edges, resultFile)
+		if err != nil {
+			return err
+		}
+	}
+
+	data, err := json.Marshal(report)
+	if err != nil {
+		return err
+	}
+	fmt.Println(string(data))
+	return os.WriteFile(reportPath, data, 0644)
+}
+
+// readInput reads the input graph in the given format, detecting it when empty, and reports
+// whether it is directed. Reading stops after the first edge over maxEdges, so that large
+// inputs are skipped without loading them.
+func readInput(r io.Reader, format string, directed bool, maxEdges int) ([]reference.Edge, bool, error) {
+	var (
+		reader      glgraph.Reader
+		inputFormat glgraph.Format
+		err         error
+	)
+	if format == "" {
+		reader, inputFormat, err = glgraph.NewDetectingReader(r)
+	} else {
+		if inputFormat, err = glgraph.ParseFormat(format); err != nil {
+			return nil, false, err
+		}
+		reader, err = glgraph.NewReader(r, inputFormat)
+	}
+	if err != nil {
+		return nil, false, err
+	}
+
+	switch inputFormat {
+	case glgraph.EdgeList, glgraph.CSV, glgraph.TSV:
+	default:
+		directed = reader.Header().Directed
+	}
+
+	var edges []reference.Edge
+	for len(edges) <= maxEdges {
+		edge, err := reader.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			return nil, false, err
+		}
+		edges = append(edges, reference.Edge{Source: edge.Source, Target: edge.Target, Weight: edge.Weight})
+	}
+	return edges, directed, nil
+}